@@ -0,0 +1,17 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	pgs "github.com/lyft/protoc-gen-star"
+	"htdvisser.dev/protoc-gen-collection/internal/genopenapi"
+)
+
+func main() {
+	pgs.Init(
+		pgs.DebugEnv("DEBUG"),
+	).RegisterModule(
+		genopenapi.OpenAPI(),
+	).Render()
+}
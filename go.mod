@@ -1,11 +1,24 @@
 module htdvisser.dev/protoc-gen-collection
 
-go 1.16
+go 1.23
 
 require (
+	buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go v1.36.11-20260709200747-435963d16310.1
 	github.com/envoyproxy/protoc-gen-validate v0.6.1
 	github.com/json-iterator/go v1.1.11
 	github.com/lyft/protoc-gen-star v0.5.3
 	google.golang.org/genproto v0.0.0-20210701191553-46259e63a0a9
+	google.golang.org/protobuf v1.36.11
 	gopkg.in/yaml.v2 v2.4.0
 )
+
+require (
+	github.com/golang/protobuf v1.5.4 // indirect
+	github.com/modern-go/concurrent v0.0.0-20180228061459-e0a39a4cb421 // indirect
+	github.com/modern-go/reflect2 v0.0.0-20180701023420-4b7aa43c6742 // indirect
+	github.com/spf13/afero v1.3.4 // indirect
+	golang.org/x/net v0.0.0-20210405180319-a5a99cb37ef4 // indirect
+	golang.org/x/sys v0.0.0-20210510120138-977fb7262007 // indirect
+	golang.org/x/text v0.3.5 // indirect
+	google.golang.org/grpc v1.38.0 // indirect
+)
@@ -0,0 +1,45 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package gendatafiles
+
+import "strings"
+
+type FieldRef struct {
+	Path string `json:"path" yaml:"path"`
+	Ref  `yaml:",inline"`
+}
+
+type MethodSignature struct {
+	Fields []FieldRef `json:"fields" yaml:"fields"`
+}
+
+func (m *Method) AddMethodSignatures(src []string) {
+	for _, signature := range src {
+		methodSignature := MethodSignature{Fields: []FieldRef{}}
+		for _, path := range strings.Split(signature, ",") {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+			field, err := LookupField(m.src.Input(), path)
+			if err != nil {
+				m.addError(err)
+				continue
+			}
+			methodSignature.Fields = append(methodSignature.Fields, FieldRef{
+				Path: path,
+				Ref:  BuildRef(field),
+			})
+		}
+		m.Signatures = append(m.Signatures, methodSignature)
+	}
+}
+
+func (s *Service) AddOAuthScopes(src string) {
+	for _, scope := range strings.Split(src, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			s.OAuthScopes = append(s.OAuthScopes, scope)
+		}
+	}
+}
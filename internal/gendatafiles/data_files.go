@@ -5,13 +5,16 @@ package gendatafiles
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"strings"
 	"unicode"
 
+	bufvalidate "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
 	"github.com/envoyproxy/protoc-gen-validate/validate"
 	pgs "github.com/lyft/protoc-gen-star"
 	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/genproto/googleapis/longrunning"
 )
 
 type Encoder interface {
@@ -21,8 +24,9 @@ type Encoder interface {
 
 type DataFilesModule struct {
 	*pgs.ModuleBase
-	encoder  Encoder
-	packages map[string]pgs.Package
+	encoder     Encoder
+	packages    map[string]pgs.Package
+	strictRules bool
 }
 
 func DataFiles(encoder Encoder) *DataFilesModule {
@@ -36,6 +40,9 @@ func DataFiles(encoder Encoder) *DataFilesModule {
 func (m *DataFilesModule) Name() string { return "data_files" }
 
 func (m *DataFilesModule) Execute(targets map[string]pgs.File, packages map[string]pgs.Package) []pgs.Artifact {
+	strictRules, err := m.Parameters().BoolDefault("strict_rules", false)
+	m.CheckErr(err, "invalid strict_rules parameter")
+	m.strictRules = strictRules
 	for _, pkg := range packages {
 		m.generatePackage(pkg)
 	}
@@ -48,23 +55,35 @@ func EntityName(entity pgs.Entity) pgs.Name {
 
 func (m *DataFilesModule) generatePackage(pkg pgs.Package) {
 	basePath := []string{"api", pkg.ProtoName().String()}
+	inlineNested := m.Parameters().Str("nested") == "inline"
 	for _, file := range pkg.Files() {
 		if !file.BuildTarget() {
 			continue
 		}
-		for _, enum := range file.AllEnums() {
-			if content, err := m.encoder.EncodeData(BuildEnum(enum)); err != nil {
+		enums, messages := file.AllEnums(), file.AllMessages()
+		if inlineNested {
+			enums, messages = file.Enums(), file.Messages()
+		}
+		for _, enum := range enums {
+			data := BuildEnum(enum)
+			m.addErrors(data.Errors())
+			if content, err := m.encoder.EncodeData(data); err != nil {
 				m.AddError(err.Error())
 			} else {
 				filename := fmt.Sprintf("%s.%s", EntityName(enum).String(), m.encoder.FileExtension())
 				m.OverwriteCustomFile(m.JoinPath(append(basePath, "enums", filename)...), content, 0644)
 			}
 		}
-		for _, message := range file.AllMessages() {
+		for _, message := range messages {
 			if message.IsMapEntry() {
 				continue
 			}
-			if content, err := m.encoder.EncodeData(BuildMessage(message)); err != nil {
+			data := BuildMessage(message)
+			if inlineNested {
+				data = BuildMessageTree(message)
+			}
+			m.addErrors(data.Errors())
+			if content, err := m.encoder.EncodeData(data); err != nil {
 				m.AddError(err.Error())
 			} else {
 				filename := fmt.Sprintf("%s.%s", EntityName(message).String(), m.encoder.FileExtension())
@@ -72,7 +91,9 @@ func (m *DataFilesModule) generatePackage(pkg pgs.Package) {
 			}
 		}
 		for _, service := range file.Services() {
-			if content, err := m.encoder.EncodeData(BuildService(service)); err != nil {
+			data := BuildService(service)
+			m.addErrors(data.Errors())
+			if content, err := m.encoder.EncodeData(data); err != nil {
 				m.AddError(err.Error())
 			} else {
 				filename := fmt.Sprintf("%s.%s", EntityName(service).String(), m.encoder.FileExtension())
@@ -82,10 +103,68 @@ func (m *DataFilesModule) generatePackage(pkg pgs.Package) {
 	}
 }
 
+func (m *DataFilesModule) addErrors(errs []error) {
+	for _, err := range errs {
+		if errors.Is(err, ErrInvalidRules) && !m.strictRules || errors.Is(err, ErrUnresolvedOptions) {
+			m.Logf("warning: %s", err)
+			continue
+		}
+		m.AddError(err.Error())
+	}
+}
+
 type Entity struct {
-	src     pgs.Entity
-	Name    pgs.Name `json:"name" yaml:"name"`
-	Comment string   `json:"comment,omitempty" yaml:"comment,omitempty"`
+	src                     pgs.Entity
+	errors                  []error
+	Name                    pgs.Name  `json:"name" yaml:"name"`
+	Comment                 string    `json:"comment,omitempty" yaml:"comment,omitempty"`
+	LeadingComment          string    `json:"leading_comment,omitempty" yaml:"leading_comment,omitempty"`
+	TrailingComment         string    `json:"trailing_comment,omitempty" yaml:"trailing_comment,omitempty"`
+	LeadingDetachedComments []string  `json:"leading_detached_comments,omitempty" yaml:"leading_detached_comments,omitempty"`
+	Deprecated              bool      `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	DeprecationMessage      string    `json:"deprecation_message,omitempty" yaml:"deprecation_message,omitempty"`
+	Location                *Location `json:"location,omitempty" yaml:"location,omitempty"`
+	Options                 MapSlice  `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+func (e *Entity) addError(err error) {
+	e.errors = append(e.errors, fmt.Errorf("%s: %w", strings.TrimPrefix(e.src.FullyQualifiedName(), "."), err))
+}
+
+func (e Entity) Errors() []error {
+	return e.errors
+}
+
+type Location struct {
+	File        string `json:"file" yaml:"file"`
+	StartLine   int32  `json:"start_line" yaml:"start_line"`
+	StartColumn int32  `json:"start_column" yaml:"start_column"`
+	EndLine     int32  `json:"end_line" yaml:"end_line"`
+	EndColumn   int32  `json:"end_column" yaml:"end_column"`
+}
+
+func BuildLocation(src pgs.Entity) *Location {
+	info := src.SourceCodeInfo()
+	if info == nil {
+		return nil
+	}
+	span := info.Location().GetSpan()
+	location := &Location{
+		File: src.File().InputPath().String(),
+	}
+	// Spans are zero-based with an exclusive end column, and have 3 elements
+	// if the declaration ends on the same line as it starts.
+	switch len(span) {
+	case 3:
+		location.StartLine, location.StartColumn = span[0]+1, span[1]+1
+		location.EndLine, location.EndColumn = span[0]+1, span[2]
+	case 4:
+		location.StartLine, location.StartColumn = span[0]+1, span[1]+1
+		location.EndLine, location.EndColumn = span[2]+1, span[3]
+	default:
+		return nil
+	}
+	return location
 }
 
 func cleanComments(comments string) string {
@@ -109,19 +188,101 @@ func cleanComments(comments string) string {
 	return strings.TrimRightFunc(strings.Join(commentLines, "\n"), unicode.IsSpace)
 }
 
-func BuildEntity(src pgs.Entity) Entity {
-	comments := src.SourceCodeInfo().LeadingComments()
-	if comments == "" {
-		comments = src.SourceCodeInfo().TrailingComments()
+func deprecationMessage(comments string) (string, bool) {
+	for _, paragraph := range strings.Split(comments, "\n\n") {
+		if strings.HasPrefix(paragraph, "Deprecated:") {
+			return strings.TrimSpace(strings.TrimPrefix(paragraph, "Deprecated:")), true
+		}
 	}
+	return "", false
+}
+
+func isDeprecated(src pgs.Entity) bool {
+	switch src := src.(type) {
+	case pgs.Message:
+		return src.Descriptor().GetOptions().GetDeprecated()
+	case pgs.Field:
+		return src.Descriptor().GetOptions().GetDeprecated()
+	case pgs.Enum:
+		return src.Descriptor().GetOptions().GetDeprecated()
+	case pgs.EnumValue:
+		return src.Descriptor().GetOptions().GetDeprecated()
+	case pgs.Service:
+		return src.Descriptor().GetOptions().GetDeprecated()
+	case pgs.Method:
+		return src.Descriptor().GetOptions().GetDeprecated()
+	default:
+		return false
+	}
+}
+
+func BuildEntity(src pgs.Entity) Entity {
 	entity := Entity{
-		src:     src,
-		Name:    src.Name(),
-		Comment: cleanComments(comments),
+		src:        src,
+		Name:       src.Name(),
+		Deprecated: isDeprecated(src),
+		Location:   BuildLocation(src),
+	}
+	options, err := BuildOptions(src.File(), entityOptions(src))
+	if err != nil {
+		entity.addError(err)
+	}
+	entity.Options = options
+	if info := src.SourceCodeInfo(); info != nil {
+		entity.LeadingComment = cleanComments(info.LeadingComments())
+		entity.TrailingComment = cleanComments(info.TrailingComments())
+		for _, comments := range info.LeadingDetachedComments() {
+			entity.LeadingDetachedComments = append(entity.LeadingDetachedComments, cleanComments(comments))
+		}
+		if message, ok := deprecationMessage(entity.LeadingComment); ok {
+			entity.Deprecated, entity.DeprecationMessage = true, message
+		}
+		entity.Comment = entity.LeadingComment
+		if entity.Comment == "" {
+			entity.Comment = entity.TrailingComment
+		}
 	}
 	return entity
 }
 
+func LookupField(src pgs.Message, path string) (pgs.Field, error) {
+	var field pgs.Field
+	for i, name := range strings.Split(path, ".") {
+		if i > 0 {
+			if field.Type().IsRepeated() || field.Type().IsMap() || !field.Type().IsEmbed() {
+				return nil, fmt.Errorf("field %q in path %q is not a singular message", field.Name(), path)
+			}
+			src = field.Type().Embed()
+		}
+		field = nil
+		for _, candidate := range src.Fields() {
+			if candidate.Name().String() == name {
+				field = candidate
+				break
+			}
+		}
+		if field == nil {
+			return nil, fmt.Errorf("message %s has no field %q", EntityName(src), name)
+		}
+	}
+	return field, nil
+}
+
+func LookupMessage(src pgs.Entity, name string) (pgs.Message, error) {
+	candidates := []string{"." + name, "." + src.Package().ProtoName().String() + "." + name}
+	files := append([]pgs.File{src.File()}, src.File().TransitiveImports()...)
+	for _, candidate := range candidates {
+		for _, file := range files {
+			for _, message := range file.AllMessages() {
+				if message.FullyQualifiedName() == candidate {
+					return message, nil
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("message %q not found", name)
+}
+
 type Ref struct {
 	src     pgs.Entity
 	Package pgs.Name `json:"package,omitempty" yaml:"package,omitempty"`
@@ -139,10 +300,20 @@ func BuildRef(src pgs.Entity) Ref {
 	return ref
 }
 
+// PackageName returns the package of the referenced entity. Unlike Package,
+// it is also set if the entity is in one of the build targets.
+func (r Ref) PackageName() pgs.Name {
+	if r.src == nil {
+		return r.Package
+	}
+	return r.src.Package().ProtoName()
+}
+
 type EnumValue struct {
-	src    pgs.EnumValue
-	Entity `yaml:",inline"`
-	Value  int32 `json:"value" yaml:"value"`
+	src     pgs.EnumValue
+	Entity  `yaml:",inline"`
+	Value   int32       `json:"value" yaml:"value"`
+	Aliases []EnumValue `json:"aliases,omitempty" yaml:"aliases,omitempty"`
 }
 
 func BuildEnumValue(src pgs.EnumValue) EnumValue {
@@ -154,24 +325,84 @@ func BuildEnumValue(src pgs.EnumValue) EnumValue {
 	return value
 }
 
+type ReservedRange struct {
+	Start int32 `json:"start" yaml:"start"`
+	End   int32 `json:"end" yaml:"end"`
+	Max   bool  `json:"max,omitempty" yaml:"max,omitempty"`
+}
+
+const (
+	maxFieldNumber     = 1<<29 - 1
+	maxEnumValueNumber = 1<<31 - 1
+)
+
+func BuildEnumType(src pgs.Enum) string {
+	if src.Syntax() == pgs.Proto3 {
+		return "open"
+	}
+	return "closed"
+}
+
+func BuildParentRef(src pgs.ParentEntity) *Ref {
+	parent, ok := src.(pgs.Message)
+	if !ok {
+		return nil
+	}
+	ref := BuildRef(parent)
+	return &ref
+}
+
 type Enum struct {
-	src    pgs.Enum
-	Entity `yaml:",inline"`
-	Values []EnumValue
+	src            pgs.Enum
+	Entity         `yaml:",inline"`
+	Parent         *Ref            `json:"parent,omitempty" yaml:"parent,omitempty"`
+	EnumType       string          `json:"enum_type" yaml:"enum_type"`
+	AllowAlias     bool            `json:"allow_alias,omitempty" yaml:"allow_alias,omitempty"`
+	Values         []EnumValue     `json:"values,omitempty" yaml:"values,omitempty"`
+	ReservedRanges []ReservedRange `json:"reserved_ranges,omitempty" yaml:"reserved_ranges,omitempty"`
+	ReservedNames  []string        `json:"reserved_names,omitempty" yaml:"reserved_names,omitempty"`
 }
 
 func BuildEnum(src pgs.Enum) Enum {
 	enum := Enum{
-		src:    src,
-		Entity: BuildEntity(src),
+		src:           src,
+		Entity:        BuildEntity(src),
+		Parent:        BuildParentRef(src.Parent()),
+		EnumType:      BuildEnumType(src),
+		AllowAlias:    src.Descriptor().GetOptions().GetAllowAlias(),
+		ReservedNames: src.Descriptor().GetReservedName(),
 	}
 	enum.Name = EntityName(src)
+	canonical := make(map[int32]int)
 	for _, value := range src.Values() {
+		if i, ok := canonical[value.Value()]; ok {
+			enum.Values[i].Aliases = append(enum.Values[i].Aliases, BuildEnumValue(value))
+			continue
+		}
+		canonical[value.Value()] = len(enum.Values)
 		enum.Values = append(enum.Values, BuildEnumValue(value))
 	}
+	for _, reserved := range src.Descriptor().GetReservedRange() {
+		enum.ReservedRanges = append(enum.ReservedRanges, ReservedRange{
+			Start: reserved.GetStart(),
+			End:   reserved.GetEnd(), // Enum reserved ranges are inclusive.
+			Max:   reserved.GetEnd() == maxEnumValueNumber,
+		})
+	}
 	return enum
 }
 
+func (e Enum) Errors() []error {
+	errors := e.Entity.Errors()
+	for _, value := range e.Values {
+		errors = append(errors, value.Errors()...)
+		for _, alias := range value.Aliases {
+			errors = append(errors, alias.Errors()...)
+		}
+	}
+	return errors
+}
+
 type FieldTypeElem struct {
 	Type    string     `json:"type,omitempty" yaml:"type,omitempty"`
 	Enum    Ref        `json:"enum,omitempty" yaml:"enum,omitempty"`
@@ -216,6 +447,23 @@ func ProtoTypeString(t pgs.ProtoType) string {
 	}
 }
 
+func ProtoTypeWireType(t pgs.ProtoType) string {
+	switch t {
+	case pgs.Int64T, pgs.UInt64T, pgs.Int32T, pgs.BoolT, pgs.UInt32T, pgs.EnumT, pgs.SInt32, pgs.SInt64:
+		return "varint"
+	case pgs.DoubleT, pgs.Fixed64T, pgs.SFixed64:
+		return "i64"
+	case pgs.StringT, pgs.BytesT, pgs.MessageT:
+		return "len"
+	case pgs.GroupT:
+		return "sgroup"
+	case pgs.FloatT, pgs.Fixed32T, pgs.SFixed32:
+		return "i32"
+	default:
+		panic(fmt.Errorf("unexpected ProtoType %q", t))
+	}
+}
+
 type Bytes []byte
 
 func (b Bytes) MarshalYAML() (interface{}, error) {
@@ -330,31 +578,126 @@ func BuildFieldDefault(src pgs.FieldType) interface{} {
 	}
 }
 
+func jsonName(name pgs.Name) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name.String() {
+		switch {
+		case r == '_':
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func BuildFieldEncoding(src pgs.Field) string {
+	t := src.Type()
+	if !t.IsRepeated() || t.IsMap() {
+		return ""
+	}
+	switch t.Element().ProtoType() {
+	case pgs.StringT, pgs.BytesT, pgs.MessageT, pgs.GroupT:
+		return ""
+	}
+	packed := src.Syntax() == pgs.Proto3
+	if opts := src.Descriptor().GetOptions(); opts != nil && opts.Packed != nil {
+		packed = opts.GetPacked()
+	}
+	if packed {
+		return "packed"
+	}
+	return "unpacked"
+}
+
+func BuildFieldPresence(src pgs.Field) string {
+	switch {
+	case src.Required():
+		return "required"
+	case src.Type().IsRepeated(), src.Type().IsMap():
+		return "implicit"
+	case src.Descriptor().GetProto3Optional(), src.InOneOf(), src.Type().IsEmbed():
+		return "explicit"
+	case src.Syntax() == pgs.Proto3:
+		return "implicit"
+	default:
+		return "explicit"
+	}
+}
+
 type Field struct {
-	src       pgs.Field
-	Entity    `yaml:",inline"`
-	FieldType `yaml:",inline"`
-	Default   interface{} `json:"default" yaml:"default"`
+	src               pgs.Field
+	Entity            `yaml:",inline"`
+	Number            int32  `json:"number" yaml:"number"`
+	JSONName          string `json:"json_name" yaml:"json_name"`
+	FieldType         `yaml:",inline"`
+	WireType          string             `json:"wire_type" yaml:"wire_type"`
+	Encoding          string             `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+	Presence          string             `json:"presence" yaml:"presence"`
+	OneOf             pgs.Name           `json:"oneof,omitempty" yaml:"oneof,omitempty"`
+	Behavior          []string           `json:"behavior,omitempty" yaml:"behavior,omitempty"`
+	ResourceReference *ResourceReference `json:"resource_reference,omitempty" yaml:"resource_reference,omitempty"`
+	Default           interface{}        `json:"default" yaml:"default"`
 }
 
 func BuildField(src pgs.Field) Field {
 	field := Field{
 		src:       src,
 		Entity:    BuildEntity(src),
+		Number:    src.Descriptor().GetNumber(),
+		JSONName:  src.Descriptor().GetJsonName(),
 		FieldType: BuildFieldType(src.Type()),
+		WireType:  ProtoTypeWireType(src.Type().ProtoType()),
+		Encoding:  BuildFieldEncoding(src),
+		Presence:  BuildFieldPresence(src),
 		Default:   BuildFieldDefault(src.Type()),
 	}
+	if src.InOneOf() && !IsSyntheticOneOf(src.OneOf()) {
+		field.OneOf = src.OneOf().Name()
+	}
+	if field.JSONName == "" {
+		field.JSONName = jsonName(src.Name())
+	}
+	if field.Encoding == "packed" {
+		field.WireType = ProtoTypeWireType(pgs.BytesT)
+	}
 	var fieldRules validate.FieldRules
 	if ok, _ := src.Extension(validate.E_Rules, &fieldRules); ok {
+		field.checkRuleConflicts(func(f *Field) { f.AddFieldRules(&fieldRules) })
 		field.AddFieldRules(&fieldRules)
 	}
+	var protovalidateRules bufvalidate.FieldRules
+	if ok, _ := src.Extension(bufvalidate.E_Field, &protovalidateRules); ok {
+		field.checkRuleConflicts(func(f *Field) { f.AddProtovalidateRules(&protovalidateRules) })
+		field.AddProtovalidateRules(&protovalidateRules)
+	}
+	var fieldBehavior []annotations.FieldBehavior
+	if ok, _ := src.Extension(annotations.E_FieldBehavior, &fieldBehavior); ok {
+		for _, behavior := range fieldBehavior {
+			field.Behavior = append(field.Behavior, behavior.String())
+		}
+	}
+	var resourceReference annotations.ResourceReference
+	if ok, _ := src.Extension(annotations.E_ResourceReference, &resourceReference); ok {
+		field.AddResourceReference(&resourceReference)
+	}
 	return field
 }
 
+func IsSyntheticOneOf(src pgs.OneOf) bool {
+	fields := src.Fields()
+	return len(fields) == 1 && fields[0].Descriptor().GetProto3Optional()
+}
+
 type OneOf struct {
 	src        pgs.OneOf
 	Entity     `yaml:",inline"`
 	FieldNames []pgs.Name `json:"field_names,omitempty" yaml:"field_names,omitempty"`
+	Required   bool       `json:"required,omitempty" yaml:"required,omitempty"`
 }
 
 func BuildOneOf(src pgs.OneOf) OneOf {
@@ -365,42 +708,128 @@ func BuildOneOf(src pgs.OneOf) OneOf {
 	for _, field := range src.Fields() {
 		oneof.FieldNames = append(oneof.FieldNames, field.Name())
 	}
+	var required bool
+	if ok, _ := src.Extension(validate.E_Required, &required); ok {
+		oneof.Required = required
+	}
+	var protovalidateRules bufvalidate.OneofRules
+	if ok, _ := src.Extension(bufvalidate.E_Oneof, &protovalidateRules); ok {
+		oneof.AddProtovalidateRules(&protovalidateRules)
+	}
 	return oneof
 }
 
 type Message struct {
-	src    pgs.Message
-	Entity `yaml:",inline"`
-	Fields []Field `json:"fields,omitempty" yaml:"fields,omitempty"`
-	OneOfs []OneOf `json:"oneofs,omitempty" yaml:"oneofs,omitempty"`
+	src            pgs.Message
+	Entity         `yaml:",inline"`
+	Parent         *Ref            `json:"parent,omitempty" yaml:"parent,omitempty"`
+	Resource       *Resource       `json:"resource,omitempty" yaml:"resource,omitempty"`
+	Rules          *MessageRules   `json:"rules,omitempty" yaml:"rules,omitempty"`
+	Fields         []Field         `json:"fields,omitempty" yaml:"fields,omitempty"`
+	OneOfs         []OneOf         `json:"oneofs,omitempty" yaml:"oneofs,omitempty"`
+	ReservedRanges []ReservedRange `json:"reserved_ranges,omitempty" yaml:"reserved_ranges,omitempty"`
+	ReservedNames  []string        `json:"reserved_names,omitempty" yaml:"reserved_names,omitempty"`
+	NestedMessages []Ref           `json:"nested_messages,omitempty" yaml:"nested_messages,omitempty"`
+	NestedEnums    []Ref           `json:"nested_enums,omitempty" yaml:"nested_enums,omitempty"`
+	Messages       []Message       `json:"messages,omitempty" yaml:"messages,omitempty"`
+	Enums          []Enum          `json:"enums,omitempty" yaml:"enums,omitempty"`
 }
 
 func BuildMessage(src pgs.Message) Message {
 	message := Message{
-		src:    src,
-		Entity: BuildEntity(src),
+		src:           src,
+		Entity:        BuildEntity(src),
+		Parent:        BuildParentRef(src.Parent()),
+		ReservedNames: src.Descriptor().GetReservedName(),
 	}
 	message.Name = EntityName(src)
+	for _, nested := range src.Messages() {
+		if nested.IsMapEntry() {
+			continue
+		}
+		message.NestedMessages = append(message.NestedMessages, BuildRef(nested))
+	}
+	for _, nested := range src.Enums() {
+		message.NestedEnums = append(message.NestedEnums, BuildRef(nested))
+	}
+	var resource annotations.ResourceDescriptor
+	if ok, _ := src.Extension(annotations.E_Resource, &resource); ok {
+		message.AddResource(&resource)
+	}
+	var disabled, ignored bool
+	if ok, _ := src.Extension(validate.E_Disabled, &disabled); ok && disabled {
+		message.rules().Disabled = true
+	}
+	if ok, _ := src.Extension(validate.E_Ignored, &ignored); ok && ignored {
+		message.rules().Ignored = true
+	}
+	var protovalidateRules bufvalidate.MessageRules
+	if ok, _ := src.Extension(bufvalidate.E_Message, &protovalidateRules); ok {
+		message.AddProtovalidateRules(&protovalidateRules)
+	}
+	for _, reserved := range src.Descriptor().GetReservedRange() {
+		message.ReservedRanges = append(message.ReservedRanges, ReservedRange{
+			Start: reserved.GetStart(),
+			End:   reserved.GetEnd() - 1, // Message reserved ranges are exclusive.
+			Max:   reserved.GetEnd()-1 == maxFieldNumber,
+		})
+	}
 	for _, field := range src.Fields() {
 		message.Fields = append(message.Fields, BuildField(field))
 	}
 	for _, oneof := range src.OneOfs() {
+		if IsSyntheticOneOf(oneof) {
+			continue
+		}
 		message.OneOfs = append(message.OneOfs, BuildOneOf(oneof))
 	}
 	return message
 }
 
+func (m Message) Errors() []error {
+	errors := m.Entity.Errors()
+	for _, field := range m.Fields {
+		errors = append(errors, field.Errors()...)
+	}
+	for _, oneof := range m.OneOfs {
+		errors = append(errors, oneof.Errors()...)
+	}
+	for _, enum := range m.Enums {
+		errors = append(errors, enum.Errors()...)
+	}
+	for _, message := range m.Messages {
+		errors = append(errors, message.Errors()...)
+	}
+	return errors
+}
+
+func BuildMessageTree(src pgs.Message) Message {
+	message := BuildMessage(src)
+	for _, nested := range src.Messages() {
+		if nested.IsMapEntry() {
+			continue
+		}
+		message.Messages = append(message.Messages, BuildMessageTree(nested))
+	}
+	for _, nested := range src.Enums() {
+		message.Enums = append(message.Enums, BuildEnum(nested))
+	}
+	return message
+}
+
 type Stream struct {
 	Ref    `yaml:",inline"`
 	Stream bool `json:"stream,omitempty" yaml:"stream,omitempty"`
 }
 
 type Method struct {
-	src    pgs.Method
-	Entity `yaml:",inline"`
-	Input  Stream     `json:"input" yaml:"input"`
-	Output Stream     `json:"output" yaml:"output"`
-	HTTP   []HTTPRule `json:"http,omitempty" yaml:"http,omitempty"`
+	src        pgs.Method
+	Entity     `yaml:",inline"`
+	Input      Stream            `json:"input" yaml:"input"`
+	Output     Stream            `json:"output" yaml:"output"`
+	HTTP       []HTTPRule        `json:"http,omitempty" yaml:"http,omitempty"`
+	Signatures []MethodSignature `json:"signatures,omitempty" yaml:"signatures,omitempty"`
+	Operation  *OperationInfo    `json:"operation,omitempty" yaml:"operation,omitempty"`
 }
 
 func BuildMethod(src pgs.Method) Method {
@@ -420,13 +849,23 @@ func BuildMethod(src pgs.Method) Method {
 	if ok, _ := src.Extension(annotations.E_Http, &httpRules); ok {
 		method.AddHTTPRules(&httpRules)
 	}
+	var methodSignatures []string
+	if ok, _ := src.Extension(annotations.E_MethodSignature, &methodSignatures); ok {
+		method.AddMethodSignatures(methodSignatures)
+	}
+	var operationInfo longrunning.OperationInfo
+	if ok, _ := src.Extension(longrunning.E_OperationInfo, &operationInfo); ok {
+		method.AddOperationInfo(&operationInfo)
+	}
 	return method
 }
 
 type Service struct {
-	src     pgs.Service
-	Entity  `yaml:",inline"`
-	Methods MapSlice `json:"methods,omitempty" yaml:"methods,omitempty"`
+	src         pgs.Service
+	Entity      `yaml:",inline"`
+	DefaultHost string   `json:"default_host,omitempty" yaml:"default_host,omitempty"`
+	OAuthScopes []string `json:"oauth_scopes,omitempty" yaml:"oauth_scopes,omitempty"`
+	Methods     MapSlice `json:"methods,omitempty" yaml:"methods,omitempty"`
 }
 
 func BuildService(src pgs.Service) Service {
@@ -434,6 +873,14 @@ func BuildService(src pgs.Service) Service {
 		src:    src,
 		Entity: BuildEntity(src),
 	}
+	var defaultHost string
+	if ok, _ := src.Extension(annotations.E_DefaultHost, &defaultHost); ok {
+		service.DefaultHost = defaultHost
+	}
+	var oauthScopes string
+	if ok, _ := src.Extension(annotations.E_OauthScopes, &oauthScopes); ok {
+		service.AddOAuthScopes(oauthScopes)
+	}
 	for _, method := range src.Methods() {
 		service.Methods = append(service.Methods, MapItem{
 			Key:   method.Name().String(),
@@ -442,3 +889,11 @@ func BuildService(src pgs.Service) Service {
 	}
 	return service
 }
+
+func (s Service) Errors() []error {
+	errors := s.Entity.Errors()
+	for _, method := range s.Methods {
+		errors = append(errors, method.Value.(Method).Errors()...)
+	}
+	return errors
+}
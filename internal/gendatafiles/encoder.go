@@ -30,3 +30,14 @@ func (YAMLEncoder) EncodeData(v interface{}) (string, error) {
 	}
 	return string(b), nil
 }
+
+type TOMLEncoder struct{}
+
+func (TOMLEncoder) FileExtension() string { return "toml" }
+func (TOMLEncoder) EncodeData(v interface{}) (string, error) {
+	b, err := marshalTOML(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
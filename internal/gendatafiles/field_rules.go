@@ -7,8 +7,25 @@ import (
 	"time"
 
 	"github.com/envoyproxy/protoc-gen-validate/validate"
+	pgs "github.com/lyft/protoc-gen-star"
 )
 
+type MessageRules struct {
+	Disabled bool               `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Ignored  bool               `json:"ignored,omitempty" yaml:"ignored,omitempty"`
+	Oneofs   []MessageOneofRule `json:"oneofs,omitempty" yaml:"oneofs,omitempty"`
+	CEL      []CELRule          `json:"cel,omitempty" yaml:"cel,omitempty"`
+}
+
+// rules returns the rules of the message, which are only allocated once the
+// message has any.
+func (m *Message) rules() *MessageRules {
+	if m.Rules == nil {
+		m.Rules = &MessageRules{}
+	}
+	return m.Rules
+}
+
 type FieldRules struct {
 	// Repeated
 	MinItems uint64 `json:"min_items,omitempty" yaml:"min_items,omitempty"`
@@ -37,6 +54,22 @@ type FieldRules struct {
 	URIRef   bool   `json:"uri_ref,omitempty" yaml:"uri_ref,omitempty"`
 	Address  bool   `json:"address,omitempty" yaml:"address,omitempty"`
 	UUID     bool   `json:"uuid,omitempty" yaml:"uuid,omitempty"`
+	TUUID    bool   `json:"tuuid,omitempty" yaml:"tuuid,omitempty"`
+	ULID     bool   `json:"ulid,omitempty" yaml:"ulid,omitempty"`
+	// Network
+	HostAndPort       bool `json:"host_and_port,omitempty" yaml:"host_and_port,omitempty"`
+	IPWithPrefixLen   bool `json:"ip_with_prefixlen,omitempty" yaml:"ip_with_prefixlen,omitempty"`
+	IPv4WithPrefixLen bool `json:"ipv4_with_prefixlen,omitempty" yaml:"ipv4_with_prefixlen,omitempty"`
+	IPv6WithPrefixLen bool `json:"ipv6_with_prefixlen,omitempty" yaml:"ipv6_with_prefixlen,omitempty"`
+	IPPrefix          bool `json:"ip_prefix,omitempty" yaml:"ip_prefix,omitempty"`
+	IPv4Prefix        bool `json:"ipv4_prefix,omitempty" yaml:"ipv4_prefix,omitempty"`
+	IPv6Prefix        bool `json:"ipv6_prefix,omitempty" yaml:"ipv6_prefix,omitempty"`
+	// Protobuf names
+	ProtobufFQN    bool `json:"protobuf_fqn,omitempty" yaml:"protobuf_fqn,omitempty"`
+	ProtobufDotFQN bool `json:"protobuf_dot_fqn,omitempty" yaml:"protobuf_dot_fqn,omitempty"`
+	// Well-known regex
+	WellKnownRegex string `json:"well_known_regex,omitempty" yaml:"well_known_regex,omitempty"`
+	Strict         *bool  `json:"strict,omitempty" yaml:"strict,omitempty"`
 	// String and Bytes
 	Prefix      interface{} `json:"prefix,omitempty" yaml:"prefix,omitempty"`
 	Suffix      interface{} `json:"suffix,omitempty" yaml:"suffix,omitempty"`
@@ -57,15 +90,23 @@ type FieldRules struct {
 	Gte   interface{} `json:"gte,omitempty" yaml:"gte,omitempty"`
 	In    interface{} `json:"in,omitempty" yaml:"in,omitempty"`
 	NotIn interface{} `json:"not_in,omitempty" yaml:"not_in,omitempty"`
+	// Float and Double
+	Finite bool `json:"finite,omitempty" yaml:"finite,omitempty"`
 	// Ignore empty (skip validations on zero value)
 	IgnoreEmpty bool `json:"ignore_empty,omitempty" yaml:"ignore_empty,omitempty"`
+	// Custom CEL expressions
+	CEL []CELRule `json:"cel,omitempty" yaml:"cel,omitempty"`
 }
 
 func (f *Field) AddFieldRules(src *validate.FieldRules) {
 	if src == nil {
 		return
 	}
-	fieldType := f.src.Type()
+	f.checkRuleTypes(src, src.GetRepeated().GetItems(), src.GetMap().GetKeys(), src.GetMap().GetValues())
+	f.addFieldRules(src, f.src.Type())
+}
+
+func (f *Field) addFieldRules(src *validate.FieldRules, fieldType pgs.FieldType) {
 	if rules := src.GetRepeated(); rules != nil && f.Repeated != nil {
 		f.Rules.MinItems = rules.GetMinItems()
 		f.Rules.MaxItems = rules.GetMaxItems()
@@ -428,6 +469,12 @@ func (f *FieldRules) addStringRules(src *validate.StringRules) {
 	f.URIRef = src.GetUriRef()
 	f.Address = src.GetAddress()
 	f.UUID = src.GetUuid()
+	if wellKnownRegex := src.GetWellKnownRegex(); wellKnownRegex != validate.KnownRegex_UNKNOWN {
+		f.WellKnownRegex = wellKnownRegex.String()
+	}
+	if src.Strict != nil {
+		f.Strict = src.Strict
+	}
 	f.IgnoreEmpty = src.GetIgnoreEmpty()
 }
 
@@ -489,6 +536,7 @@ func (f *FieldRules) addAnyRules(src *validate.AnyRules) {
 }
 
 func (f *FieldRules) addDurationRules(src *validate.DurationRules) {
+	f.Required = src.GetRequired()
 	if src.Const != nil {
 		f.Const = src.Const.AsDuration()
 	}
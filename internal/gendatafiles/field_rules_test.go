@@ -0,0 +1,126 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package gendatafiles
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/envoyproxy/protoc-gen-validate/validate"
+	pgs "github.com/lyft/protoc-gen-star"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// testFieldType is a field type that accepts the rules of every type.
+type testFieldType struct {
+	pgs.FieldType
+}
+
+func (testFieldType) IsEnum() bool               { return true }
+func (testFieldType) IsEmbed() bool              { return true }
+func (testFieldType) Element() pgs.FieldTypeElem { return testFieldTypeElem{} }
+func (testFieldType) Key() pgs.FieldTypeElem     { return testFieldTypeElem{} }
+
+type testFieldTypeElem struct {
+	pgs.FieldTypeElem
+}
+
+func (testFieldTypeElem) IsEnum() bool  { return true }
+func (testFieldTypeElem) IsEmbed() bool { return true }
+
+// testRuleValue returns a non-default value for a field (or list element) of
+// a rules message.
+func testRuleValue(v protoreflect.Value, fd protoreflect.FieldDescriptor) protoreflect.Value {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(true)
+	case protoreflect.EnumKind:
+		return protoreflect.ValueOfEnum(1)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(1)
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(1)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(1)
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(1)
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(1)
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(1)
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString("x")
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte("x"))
+	case protoreflect.MessageKind:
+		msg := v.Message()
+		if _, ok := msg.Interface().(*validate.FieldRules); ok {
+			// Rules of items, keys and values.
+			fd := msg.Descriptor().Fields().ByName("string")
+			msg.Set(fd, protoreflect.ValueOfMessage((&validate.StringRules{MinLen: proto.Uint64(1)}).ProtoReflect()))
+			return v
+		}
+		// Duration and Timestamp.
+		setTestRule(msg, msg.Descriptor().Fields().Get(0))
+		return v
+	}
+	panic("unsupported kind " + fd.Kind().String())
+}
+
+func setTestRule(m protoreflect.Message, fd protoreflect.FieldDescriptor) {
+	if fd.IsList() {
+		list := m.Mutable(fd).List()
+		list.Append(testRuleValue(list.NewElement(), fd))
+		return
+	}
+	m.Set(fd, testRuleValue(m.NewField(fd), fd))
+}
+
+func TestAddFieldRules(t *testing.T) {
+	fieldRules := (&validate.FieldRules{}).ProtoReflect().Descriptor().Fields()
+	for i := 0; i < fieldRules.Len(); i++ {
+		typeField := fieldRules.Get(i)
+		rules := typeField.Message().Fields()
+		for j := 0; j < rules.Len(); j++ {
+			rule := rules.Get(j)
+			t.Run(string(typeField.Name())+"."+string(rule.Name()), func(t *testing.T) {
+				src := (&validate.FieldRules{}).ProtoReflect()
+				typeRules := src.NewField(typeField).Message()
+				setTestRule(typeRules, rule)
+				src.Set(typeField, protoreflect.ValueOfMessage(typeRules))
+
+				field := Field{FieldType: FieldType{
+					Repeated: &FieldTypeElem{},
+					MapKey:   &FieldTypeElem{},
+					MapValue: &FieldTypeElem{},
+				}}
+				field.addFieldRules(src.Interface().(*validate.FieldRules), testFieldType{})
+				if reflect.ValueOf(field.Rules).IsZero() &&
+					reflect.ValueOf(field.Repeated.Rules).IsZero() &&
+					reflect.ValueOf(field.MapKey.Rules).IsZero() &&
+					reflect.ValueOf(field.MapValue.Rules).IsZero() {
+					t.Fatalf("rule %s is not mapped to FieldRules", rule.FullName())
+				}
+			})
+		}
+	}
+}
+
+// TestUnsupportedFieldRules fails when protoc-gen-validate adds rules that
+// were missing in the pinned version, so that they can be mapped as well.
+func TestUnsupportedFieldRules(t *testing.T) {
+	for _, tt := range []struct {
+		rules protoreflect.ProtoMessage
+		name  protoreflect.Name
+	}{
+		{rules: &validate.BytesRules{}, name: "not_contains"},
+		{rules: &validate.EnumRules{}, name: "ignore_empty"},
+	} {
+		descriptor := tt.rules.ProtoReflect().Descriptor()
+		if fd := descriptor.Fields().ByName(tt.name); fd != nil {
+			t.Errorf("%s now exists, map it to FieldRules", fd.FullName())
+		}
+	}
+}
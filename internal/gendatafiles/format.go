@@ -0,0 +1,110 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package gendatafiles
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Format formats the field type, using elemType to format the element types
+// and escape to escape the text around them.
+func (t FieldType) Format(elemType func(FieldTypeElem) string, escape func(string) string) string {
+	switch {
+	case t.Repeated != nil:
+		return escape("repeated ") + elemType(*t.Repeated)
+	case t.MapKey != nil:
+		return escape("map<") + elemType(*t.MapKey) + escape(", ") + elemType(*t.MapValue) + escape(">")
+	default:
+		return elemType(t.FieldTypeElem)
+	}
+}
+
+// Description returns the comment of the entity, marked if it is deprecated.
+func (e Entity) Description() string {
+	if !e.Deprecated || e.DeprecationMessage != "" {
+		return e.Comment
+	}
+	if e.Comment == "" {
+		return "Deprecated."
+	}
+	return "Deprecated. " + e.Comment
+}
+
+// FormatDefault returns the default value of the field, formatted as JSON.
+func (f Field) FormatDefault() string {
+	if f.Default == nil {
+		return ""
+	}
+	b, err := json.Marshal(f.Default)
+	if err != nil {
+		return fmt.Sprint(f.Default)
+	}
+	return string(b)
+}
+
+func formatRuleValue(v reflect.Value) string {
+	switch value := v.Interface().(type) {
+	case time.Duration:
+		return value.String()
+	case []time.Duration:
+		values := make([]string, len(value))
+		for i, d := range value {
+			values[i] = d.String()
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	}
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Sprint(v.Interface())
+	}
+	return string(b)
+}
+
+// formatRules returns the rules that are set, formatted as "name: value".
+func formatRules(prefix string, rules FieldRules) []string {
+	var formatted []string
+	v := reflect.ValueOf(rules)
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.IsZero() {
+			continue
+		}
+		name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		if field.Kind() == reflect.Bool {
+			formatted = append(formatted, prefix+name)
+			continue
+		}
+		if field.Kind() == reflect.Interface || field.Kind() == reflect.Ptr {
+			field = field.Elem()
+			if field.Kind() == reflect.Ptr {
+				field = field.Elem()
+			}
+		}
+		formatted = append(formatted, fmt.Sprintf("%s%s: %s", prefix, name, formatRuleValue(field)))
+	}
+	return formatted
+}
+
+// FormatRules returns the validation rules of the field, formatted as
+// "name: value". Rules of the items of repeated fields and of the keys and
+// values of maps are prefixed with "items.", "keys." and "values.".
+func (f Field) FormatRules() []string {
+	rules := formatRules("", f.Rules)
+	if f.Repeated != nil {
+		rules = append(rules, formatRules("items.", f.Repeated.Rules)...)
+	}
+	if f.MapKey != nil {
+		rules = append(rules, formatRules("keys.", f.MapKey.Rules)...)
+	}
+	if f.MapValue != nil {
+		rules = append(rules, formatRules("values.", f.MapValue.Rules)...)
+	}
+	return rules
+}
@@ -3,38 +3,93 @@
 
 package gendatafiles
 
-import "google.golang.org/genproto/googleapis/api/annotations"
+import (
+	"fmt"
+
+	pgs "github.com/lyft/protoc-gen-star"
+	"google.golang.org/genproto/googleapis/api/annotations"
+)
 
 type HTTPRule struct {
-	Method        string `json:"method" yaml:"method"`
-	Path          string `json:"path" yaml:"path"`
-	Input         string `json:"input,omitempty" yaml:"input,omitempty"`
-	InputMessage  string `json:"input_message,omitempty" yaml:"input_message,omitempty"`
-	Output        string `json:"output,omitempty" yaml:"output,omitempty"`
-	OutputMessage string `json:"output_message,omitempty" yaml:"output_message,omitempty"`
+	Method          string        `json:"method" yaml:"method"`
+	Path            string        `json:"path" yaml:"path"`
+	Template        *PathTemplate `json:"template,omitempty" yaml:"template,omitempty"`
+	BodyMode        string        `json:"body_mode" yaml:"body_mode"`
+	Input           string        `json:"input,omitempty" yaml:"input,omitempty"`
+	InputMessage    string        `json:"input_message,omitempty" yaml:"input_message,omitempty"`
+	InputType       *FieldType    `json:"input_type,omitempty" yaml:"input_type,omitempty"`
+	Output          string        `json:"output,omitempty" yaml:"output,omitempty"`
+	OutputMessage   string        `json:"output_message,omitempty" yaml:"output_message,omitempty"`
+	OutputType      *FieldType    `json:"output_type,omitempty" yaml:"output_type,omitempty"`
+	QueryParameters []FieldRef    `json:"query_parameters,omitempty" yaml:"query_parameters,omitempty"`
+}
+
+func httpBodyMode(body string) string {
+	switch body {
+	case "":
+		return "none"
+	case "*":
+		return "message"
+	default:
+		return "field"
+	}
+}
+
+func addQueryParameters(params []FieldRef, src pgs.Message, prefix string, excluded map[string]bool, visited map[pgs.Message]bool) []FieldRef {
+	visited[src] = true
+	defer delete(visited, src)
+	for _, field := range src.Fields() {
+		path := prefix + field.Name().String()
+		if excluded[path] {
+			continue
+		}
+		fieldType := field.Type()
+		switch {
+		case fieldType.IsMap():
+			continue
+		case fieldType.IsRepeated():
+			if fieldType.Element().IsEmbed() {
+				continue
+			}
+		case fieldType.IsEmbed() && !fieldType.Embed().IsWellKnown():
+			if !visited[fieldType.Embed()] {
+				params = addQueryParameters(params, fieldType.Embed(), path+".", excluded, visited)
+			}
+			continue
+		}
+		params = append(params, FieldRef{
+			Path: path,
+			Ref:  BuildRef(field),
+		})
+	}
+	return params
 }
 
 func (m *Method) AddHTTPRules(src *annotations.HttpRule) {
 	if src == nil {
 		return
 	}
-	httpRule := HTTPRule{}
+	httpRule := HTTPRule{
+		BodyMode: httpBodyMode(src.GetBody()),
+	}
 	if body := src.GetBody(); body != "" && body != "*" {
 		httpRule.Input = body
-		for _, field := range m.src.Input().Fields() {
-			if field.Name().String() == body {
-				httpRule.InputMessage = field.FullyQualifiedName()
-				break
-			}
+		if field, err := LookupField(m.src.Input(), body); err != nil {
+			m.addError(fmt.Errorf("body %q: %w", body, err))
+		} else {
+			fieldType := BuildFieldType(field.Type())
+			httpRule.InputMessage = field.FullyQualifiedName()
+			httpRule.InputType = &fieldType
 		}
 	}
 	if body := src.GetResponseBody(); body != "" && body != "*" {
 		httpRule.Output = body
-		for _, field := range m.src.Output().Fields() {
-			if field.Name().String() == body {
-				httpRule.OutputMessage = field.FullyQualifiedName()
-				break
-			}
+		if field, err := LookupField(m.src.Output(), body); err != nil {
+			m.addError(fmt.Errorf("response body %q: %w", body, err))
+		} else {
+			fieldType := BuildFieldType(field.Type())
+			httpRule.OutputMessage = field.FullyQualifiedName()
+			httpRule.OutputType = &fieldType
 		}
 	}
 	switch pattern := src.Pattern.(type) {
@@ -51,6 +106,32 @@ func (m *Method) AddHTTPRules(src *annotations.HttpRule) {
 	case *annotations.HttpRule_Custom:
 		httpRule.Method, httpRule.Path = pattern.Custom.GetKind(), pattern.Custom.GetPath()
 	}
+	if template, err := ParsePathTemplate(httpRule.Path); err != nil {
+		m.addError(err)
+	} else {
+		for i, variable := range template.Variables {
+			field, err := LookupField(m.src.Input(), variable.FieldPath)
+			if err != nil {
+				m.addError(fmt.Errorf("path template %q: %w", httpRule.Path, err))
+				continue
+			}
+			ref := BuildRef(field)
+			template.Variables[i].Field = &ref
+		}
+		httpRule.Template = template
+	}
+	if httpRule.BodyMode != "message" {
+		excluded := make(map[string]bool)
+		if httpRule.BodyMode == "field" {
+			excluded[src.GetBody()] = true
+		}
+		if httpRule.Template != nil {
+			for _, variable := range httpRule.Template.Variables {
+				excluded[variable.FieldPath] = true
+			}
+		}
+		httpRule.QueryParameters = addQueryParameters(nil, m.src.Input(), "", excluded, make(map[pgs.Message]bool))
+	}
 	m.HTTP = append(m.HTTP, httpRule)
 	for _, additional := range src.AdditionalBindings {
 		m.AddHTTPRules(additional)
@@ -0,0 +1,134 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package gendatafiles
+
+import (
+	"fmt"
+	"strings"
+)
+
+type PathSegment struct {
+	Literal  string `json:"literal,omitempty" yaml:"literal,omitempty"`
+	Wildcard string `json:"wildcard,omitempty" yaml:"wildcard,omitempty"`
+	Variable string `json:"variable,omitempty" yaml:"variable,omitempty"`
+}
+
+type PathVariable struct {
+	FieldPath string        `json:"field_path" yaml:"field_path"`
+	Pattern   string        `json:"pattern" yaml:"pattern"`
+	Segments  []PathSegment `json:"segments" yaml:"segments"`
+	Field     *Ref          `json:"field,omitempty" yaml:"field,omitempty"`
+}
+
+type PathTemplate struct {
+	Segments  []PathSegment  `json:"segments" yaml:"segments"`
+	Variables []PathVariable `json:"variables,omitempty" yaml:"variables,omitempty"`
+	Verb      string         `json:"verb,omitempty" yaml:"verb,omitempty"`
+}
+
+// splitTopLevel splits s on sep, ignoring separators inside variables.
+func splitTopLevel(s string, sep byte) ([]string, error) {
+	var (
+		parts []string
+		depth int
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			if depth++; depth > 1 {
+				return nil, fmt.Errorf("nested variable in %q", s)
+			}
+		case '}':
+			if depth--; depth < 0 {
+				return nil, fmt.Errorf("unexpected \"}\" in %q", s)
+			}
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unterminated variable in %q", s)
+	}
+	return append(parts, s[start:]), nil
+}
+
+func parsePathSegment(s string) (PathSegment, error) {
+	switch {
+	case s == "":
+		return PathSegment{}, fmt.Errorf("empty segment")
+	case s == "*" || s == "**":
+		return PathSegment{Wildcard: s}, nil
+	case strings.ContainsAny(s, "{}*="):
+		return PathSegment{}, fmt.Errorf("invalid literal %q", s)
+	default:
+		return PathSegment{Literal: s}, nil
+	}
+}
+
+func parsePathVariable(s string) (PathVariable, error) {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+	variable := PathVariable{FieldPath: s, Pattern: "*"}
+	if i := strings.IndexByte(s, '='); i >= 0 {
+		variable.FieldPath, variable.Pattern = s[:i], s[i+1:]
+	}
+	for _, name := range strings.Split(variable.FieldPath, ".") {
+		if name == "" || strings.ContainsAny(name, "/*{}") {
+			return PathVariable{}, fmt.Errorf("invalid field path %q", variable.FieldPath)
+		}
+	}
+	for _, part := range strings.Split(variable.Pattern, "/") {
+		segment, err := parsePathSegment(part)
+		if err != nil {
+			return PathVariable{}, fmt.Errorf("variable %q: %w", variable.FieldPath, err)
+		}
+		variable.Segments = append(variable.Segments, segment)
+	}
+	return variable, nil
+}
+
+func ParsePathTemplate(path string) (*PathTemplate, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("path template %q does not start with \"/\"", path)
+	}
+	parts, err := splitTopLevel(path[1:], '/')
+	if err != nil {
+		return nil, err
+	}
+	template := &PathTemplate{Segments: []PathSegment{}}
+	last, err := splitTopLevel(parts[len(parts)-1], ':')
+	if err != nil {
+		return nil, err
+	}
+	switch len(last) {
+	case 1:
+	case 2:
+		parts[len(parts)-1], template.Verb = last[0], last[1]
+	default:
+		return nil, fmt.Errorf("path template %q has more than one verb", path)
+	}
+	if len(parts) == 1 && parts[0] == "" {
+		return template, nil
+	}
+	for _, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			variable, err := parsePathVariable(part)
+			if err != nil {
+				return nil, fmt.Errorf("path template %q: %w", path, err)
+			}
+			template.Segments = append(template.Segments, PathSegment{Variable: variable.FieldPath})
+			template.Variables = append(template.Variables, variable)
+			continue
+		}
+		segment, err := parsePathSegment(part)
+		if err != nil {
+			return nil, fmt.Errorf("path template %q: %w", path, err)
+		}
+		template.Segments = append(template.Segments, segment)
+	}
+	return template, nil
+}
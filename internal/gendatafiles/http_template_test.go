@@ -0,0 +1,94 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package gendatafiles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePathTemplate(t *testing.T) {
+	for _, tt := range []struct {
+		path     string
+		expected *PathTemplate
+		err      bool
+	}{
+		{
+			path:     "/",
+			expected: &PathTemplate{Segments: []PathSegment{}},
+		},
+		{
+			path: "/v1/books",
+			expected: &PathTemplate{Segments: []PathSegment{
+				{Literal: "v1"}, {Literal: "books"},
+			}},
+		},
+		{
+			path: "/v1/books:search",
+			expected: &PathTemplate{
+				Segments: []PathSegment{{Literal: "v1"}, {Literal: "books"}},
+				Verb:     "search",
+			},
+		},
+		{
+			path: "/v1/{name}",
+			expected: &PathTemplate{
+				Segments: []PathSegment{{Literal: "v1"}, {Variable: "name"}},
+				Variables: []PathVariable{
+					{FieldPath: "name", Pattern: "*", Segments: []PathSegment{{Wildcard: "*"}}},
+				},
+			},
+		},
+		{
+			path: "/v1/{name=shelves/*/books/*}:get",
+			expected: &PathTemplate{
+				Segments: []PathSegment{{Literal: "v1"}, {Variable: "name"}},
+				Variables: []PathVariable{
+					{FieldPath: "name", Pattern: "shelves/*/books/*", Segments: []PathSegment{
+						{Literal: "shelves"}, {Wildcard: "*"}, {Literal: "books"}, {Wildcard: "*"},
+					}},
+				},
+				Verb: "get",
+			},
+		},
+		{
+			path: "/v1/{book.name=shelves/**}/files/**",
+			expected: &PathTemplate{
+				Segments: []PathSegment{{Literal: "v1"}, {Variable: "book.name"}, {Literal: "files"}, {Wildcard: "**"}},
+				Variables: []PathVariable{
+					{FieldPath: "book.name", Pattern: "shelves/**", Segments: []PathSegment{
+						{Literal: "shelves"}, {Wildcard: "**"},
+					}},
+				},
+			},
+		},
+		{path: "", err: true},
+		{path: "v1/books", err: true},
+		{path: "/v1/", err: true},
+		{path: "/v1//books", err: true},
+		{path: "/v1/{name", err: true},
+		{path: "/v1/name}", err: true},
+		{path: "/v1/{name={id}}", err: true},
+		{path: "/v1/{name=}", err: true},
+		{path: "/v1/{=books/*}", err: true},
+		{path: "/v1/books:a:b", err: true},
+		{path: "/v1/bo*ks", err: true},
+	} {
+		t.Run(tt.path, func(t *testing.T) {
+			template, err := ParsePathTemplate(tt.path)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", template)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(template, tt.expected) {
+				t.Fatalf("expected %+v, got %+v", tt.expected, template)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package gendatafiles
+
+import "google.golang.org/genproto/googleapis/longrunning"
+
+type OperationInfo struct {
+	Response Ref `json:"response" yaml:"response"`
+	Metadata Ref `json:"metadata" yaml:"metadata"`
+}
+
+func (m *Method) AddOperationInfo(src *longrunning.OperationInfo) {
+	if src == nil {
+		return
+	}
+	operationInfo := &OperationInfo{}
+	if response, err := LookupMessage(m.src, src.GetResponseType()); err != nil {
+		m.addError(err)
+	} else {
+		operationInfo.Response = BuildRef(response)
+	}
+	if metadata, err := LookupMessage(m.src, src.GetMetadataType()); err != nil {
+		m.addError(err)
+	} else {
+		operationInfo.Metadata = BuildRef(metadata)
+	}
+	m.Operation = operationInfo
+}
@@ -48,3 +48,34 @@ type mapSliceByKey MapSlice
 func (a mapSliceByKey) Len() int           { return len(a) }
 func (a mapSliceByKey) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a mapSliceByKey) Less(i, j int) bool { return a[i].Key < a[j].Key }
+
+// Get returns the value of key.
+func (m MapSlice) Get(key string) (interface{}, bool) {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Set sets the value of key, appending it if m does not have the key yet.
+func (m MapSlice) Set(key string, value interface{}) MapSlice {
+	for i, item := range m {
+		if item.Key == key {
+			m[i].Value = value
+			return m
+		}
+	}
+	return append(m, MapItem{Key: key, Value: value})
+}
+
+// Unset removes key from m. It does not modify the original slice.
+func (m MapSlice) Unset(key string) MapSlice {
+	for i, item := range m {
+		if item.Key == key {
+			return append(m[:i:i], m[i+1:]...)
+		}
+	}
+	return m
+}
@@ -0,0 +1,245 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package gendatafiles
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	bufvalidate "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/envoyproxy/protoc-gen-validate/validate"
+	pgs "github.com/lyft/protoc-gen-star"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/genproto/googleapis/longrunning"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ErrUnresolvedOptions is returned when the options of an entity could not be
+// resolved. Those options are kept as raw encoded fields.
+var ErrUnresolvedOptions = errors.New("unresolved options")
+
+var extensionTypesByFile = make(map[string]*protoregistry.Types)
+
+// modelledExtensions are the extensions that are already part of the data
+// files, and are therefore left out of the options.
+var modelledExtensions = make(map[protoreflect.FullName]protoreflect.ExtensionTypeDescriptor)
+
+func init() {
+	for _, ext := range []protoreflect.ExtensionType{
+		validate.E_Rules, validate.E_Disabled, validate.E_Ignored, validate.E_Required,
+		bufvalidate.E_Field, bufvalidate.E_Message, bufvalidate.E_Oneof,
+		annotations.E_Http, annotations.E_MethodSignature, annotations.E_DefaultHost, annotations.E_OauthScopes,
+		annotations.E_FieldBehavior, annotations.E_Resource, annotations.E_ResourceReference,
+		longrunning.E_OperationInfo,
+	} {
+		modelledExtensions[ext.TypeDescriptor().FullName()] = ext.TypeDescriptor()
+	}
+}
+
+func isModelledExtension(extendee protoreflect.FullName, number protoreflect.FieldNumber) bool {
+	for _, ext := range modelledExtensions {
+		if ext.ContainingMessage().FullName() == extendee && ext.Number() == number {
+			return true
+		}
+	}
+	return false
+}
+
+type extensionContainer interface {
+	Extensions() protoreflect.ExtensionDescriptors
+	Messages() protoreflect.MessageDescriptors
+}
+
+func registerExtensions(types *protoregistry.Types, src extensionContainer) {
+	for i := 0; i < src.Extensions().Len(); i++ {
+		types.RegisterExtension(dynamicpb.NewExtensionType(src.Extensions().Get(i)))
+	}
+	for i := 0; i < src.Messages().Len(); i++ {
+		registerExtensions(types, src.Messages().Get(i))
+	}
+}
+
+func extensionTypes(file pgs.File) (*protoregistry.Types, error) {
+	if types, ok := extensionTypesByFile[file.Name().String()]; ok {
+		return types, nil
+	}
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file.Descriptor()}}
+	for _, imported := range file.TransitiveImports() {
+		set.File = append(set.File, imported.Descriptor())
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, err
+	}
+	types := new(protoregistry.Types)
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		registerExtensions(types, fd)
+		return true
+	})
+	extensionTypesByFile[file.Name().String()] = types
+	return types, nil
+}
+
+func resolveOptions(file pgs.File, src protoreflect.ProtoMessage) (protoreflect.Message, error) {
+	msg := src.ProtoReflect()
+	types, err := extensionTypes(file)
+	if err != nil {
+		return msg, fmt.Errorf("%w: %v", ErrUnresolvedOptions, err)
+	}
+	b, err := proto.Marshal(src)
+	if err != nil {
+		return msg, fmt.Errorf("%w: %v", ErrUnresolvedOptions, err)
+	}
+	resolved := msg.New()
+	if err := (proto.UnmarshalOptions{Resolver: types}).Unmarshal(b, resolved.Interface()); err != nil {
+		return msg, fmt.Errorf("%w: %v", ErrUnresolvedOptions, err)
+	}
+	return resolved, nil
+}
+
+func entityOptions(src pgs.Entity) protoreflect.ProtoMessage {
+	switch src := src.(type) {
+	case pgs.Message:
+		return src.Descriptor().GetOptions()
+	case pgs.Field:
+		return src.Descriptor().GetOptions()
+	case pgs.OneOf:
+		return src.Descriptor().GetOptions()
+	case pgs.Enum:
+		return src.Descriptor().GetOptions()
+	case pgs.EnumValue:
+		return src.Descriptor().GetOptions()
+	case pgs.Service:
+		return src.Descriptor().GetOptions()
+	case pgs.Method:
+		return src.Descriptor().GetOptions()
+	default:
+		return nil
+	}
+}
+
+// BuildOptions builds the extensions that are set in the options of an entity,
+// except for the extensions that are modelled elsewhere in the data files.
+// Options that can not be resolved are kept as raw encoded fields, and the
+// reason is returned as an error that wraps ErrUnresolvedOptions.
+func BuildOptions(file pgs.File, src protoreflect.ProtoMessage) (MapSlice, error) {
+	if src == nil || !src.ProtoReflect().IsValid() {
+		return nil, nil
+	}
+	msg, err := resolveOptions(file, src)
+	var options MapSlice
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if _, ok := modelledExtensions[fd.FullName()]; ok {
+			return true
+		}
+		if fd.IsExtension() {
+			options = append(options, MapItem{
+				Key:   string(fd.FullName()),
+				Value: optionValue(fd, v),
+			})
+		}
+		return true
+	})
+	sort.Sort(mapSliceByKey(options))
+	return append(options, unknownOptions(msg.Descriptor().FullName(), msg.GetUnknown())...), err
+}
+
+// unknownOptions keeps the raw encoding of extensions that could not be
+// resolved, keyed by their field number.
+func unknownOptions(extendee protoreflect.FullName, b []byte) MapSlice {
+	var (
+		options MapSlice
+		index   = make(map[protowire.Number]int)
+	)
+	for len(b) > 0 {
+		number, _, n := protowire.ConsumeField(b)
+		if n < 0 {
+			break
+		}
+		if isModelledExtension(extendee, number) {
+			b = b[n:]
+			continue
+		}
+		key := fmt.Sprint(number)
+		if i, ok := index[number]; ok {
+			options[i].Value = append(options[i].Value.(Bytes), b[:n]...)
+		} else {
+			index[number] = len(options)
+			options = append(options, MapItem{Key: key, Value: append(Bytes(nil), b[:n]...)})
+		}
+		b = b[n:]
+	}
+	return options
+}
+
+func optionMessage(msg protoreflect.Message) MapSlice {
+	var fields []protoreflect.FieldDescriptor
+	values := make(map[protoreflect.FieldDescriptor]protoreflect.Value)
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		fields = append(fields, fd)
+		values[fd] = v
+		return true
+	})
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Number() < fields[j].Number() })
+	options := make(MapSlice, 0, len(fields))
+	for _, fd := range fields {
+		key := string(fd.Name())
+		if fd.IsExtension() {
+			key = string(fd.FullName())
+		}
+		options = append(options, MapItem{
+			Key:   key,
+			Value: optionValue(fd, values[fd]),
+		})
+	}
+	return options
+}
+
+func optionValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		values := make([]interface{}, list.Len())
+		for i := range values {
+			values[i] = optionScalar(fd, list.Get(i))
+		}
+		return values
+	case fd.IsMap():
+		var entries MapSlice
+		v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			entries = append(entries, MapItem{
+				Key:   fmt.Sprint(k.Interface()),
+				Value: optionScalar(fd.MapValue(), v),
+			})
+			return true
+		})
+		sort.Sort(mapSliceByKey(entries))
+		return entries
+	default:
+		return optionScalar(fd, v)
+	}
+}
+
+func optionScalar(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if value := fd.Enum().Values().ByNumber(v.Enum()); value != nil {
+			return string(value.Name())
+		}
+		return int32(v.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return optionMessage(v.Message())
+	case protoreflect.BytesKind:
+		return Bytes(v.Bytes())
+	default:
+		return v.Interface()
+	}
+}
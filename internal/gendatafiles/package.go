@@ -0,0 +1,41 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package gendatafiles
+
+import pgs "github.com/lyft/protoc-gen-star"
+
+type Package struct {
+	Name     pgs.Name  `json:"name" yaml:"name"`
+	Services []Service `json:"services,omitempty" yaml:"services,omitempty"`
+	Messages []Message `json:"messages,omitempty" yaml:"messages,omitempty"`
+	Enums    []Enum    `json:"enums,omitempty" yaml:"enums,omitempty"`
+}
+
+// BuildPackage builds the services, messages (including nested messages) and
+// enums of the build targets in src.
+func BuildPackage(src pgs.Package) Package {
+	pkg := Package{Name: src.ProtoName()}
+	for _, file := range src.Files() {
+		if !file.BuildTarget() {
+			continue
+		}
+		for _, service := range file.Services() {
+			pkg.Services = append(pkg.Services, BuildService(service))
+		}
+		for _, message := range file.AllMessages() {
+			if !message.IsMapEntry() {
+				pkg.Messages = append(pkg.Messages, BuildMessage(message))
+			}
+		}
+		for _, enum := range file.AllEnums() {
+			pkg.Enums = append(pkg.Enums, BuildEnum(enum))
+		}
+	}
+	return pkg
+}
+
+// IsEmpty returns true if the package has no services, messages or enums.
+func (p Package) IsEmpty() bool {
+	return len(p.Services) == 0 && len(p.Messages) == 0 && len(p.Enums) == 0
+}
@@ -0,0 +1,625 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package gendatafiles
+
+import (
+	"time"
+
+	bufvalidate "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+)
+
+type CELRule struct {
+	ID         string `json:"id,omitempty" yaml:"id,omitempty"`
+	Message    string `json:"message,omitempty" yaml:"message,omitempty"`
+	Expression string `json:"expression" yaml:"expression"`
+}
+
+func buildCELRules(rules []*bufvalidate.Rule, expressions []string) []CELRule {
+	var celRules []CELRule
+	for _, rule := range rules {
+		celRules = append(celRules, CELRule{
+			ID:         rule.GetId(),
+			Message:    rule.GetMessage(),
+			Expression: rule.GetExpression(),
+		})
+	}
+	for _, expression := range expressions {
+		celRules = append(celRules, CELRule{Expression: expression})
+	}
+	return celRules
+}
+
+type MessageOneofRule struct {
+	Fields   []string `json:"fields" yaml:"fields"`
+	Required bool     `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+func (m *Message) AddProtovalidateRules(src *bufvalidate.MessageRules) {
+	if src == nil {
+		return
+	}
+	if cel := buildCELRules(src.GetCel(), src.GetCelExpression()); len(cel) > 0 {
+		m.rules().CEL = append(m.rules().CEL, cel...)
+	}
+	for _, oneof := range src.GetOneof() {
+		m.rules().Oneofs = append(m.rules().Oneofs, MessageOneofRule{
+			Fields:   oneof.GetFields(),
+			Required: oneof.GetRequired(),
+		})
+	}
+}
+
+func (o *OneOf) AddProtovalidateRules(src *bufvalidate.OneofRules) {
+	if src == nil {
+		return
+	}
+	o.Required = src.GetRequired()
+}
+
+func (f *Field) AddProtovalidateRules(src *bufvalidate.FieldRules) {
+	if src == nil {
+		return
+	}
+	fieldType := f.src.Type()
+	f.checkRuleTypes(src, src.GetRepeated().GetItems(), src.GetMap().GetKeys(), src.GetMap().GetValues())
+	if rules := src.GetRepeated(); rules != nil && f.Repeated != nil {
+		f.Rules.MinItems = rules.GetMinItems()
+		f.Rules.MaxItems = rules.GetMaxItems()
+		f.Rules.Unique = rules.GetUnique()
+		f.Repeated.Rules.AddProtovalidateRules(rules.GetItems(), fieldType.Element())
+	}
+	if rules := src.GetMap(); rules != nil && f.MapKey != nil && f.MapValue != nil {
+		f.Rules.MinPairs = rules.GetMinPairs()
+		f.Rules.MaxPairs = rules.GetMaxPairs()
+		f.MapKey.Rules.AddProtovalidateRules(rules.GetKeys(), fieldType.Key())
+		f.MapValue.Rules.AddProtovalidateRules(rules.GetValues(), fieldType.Element())
+	}
+	f.Rules.AddProtovalidateRules(src, fieldType)
+}
+
+func (f *FieldRules) addProtovalidateFloatRules(src *bufvalidate.FloatRules) {
+	if src.HasConst() {
+		f.Const = src.GetConst()
+	}
+	if src.HasLt() {
+		f.Lt = src.GetLt()
+	}
+	if src.HasLte() {
+		f.Lte = src.GetLte()
+	}
+	if src.HasGt() {
+		f.Gt = src.GetGt()
+	}
+	if src.HasGte() {
+		f.Gte = src.GetGte()
+	}
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+	f.Finite = src.GetFinite()
+}
+
+func (f *FieldRules) addProtovalidateDoubleRules(src *bufvalidate.DoubleRules) {
+	if src.HasConst() {
+		f.Const = src.GetConst()
+	}
+	if src.HasLt() {
+		f.Lt = src.GetLt()
+	}
+	if src.HasLte() {
+		f.Lte = src.GetLte()
+	}
+	if src.HasGt() {
+		f.Gt = src.GetGt()
+	}
+	if src.HasGte() {
+		f.Gte = src.GetGte()
+	}
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+	f.Finite = src.GetFinite()
+}
+
+func (f *FieldRules) addProtovalidateInt32Rules(src *bufvalidate.Int32Rules) {
+	if src.HasConst() {
+		f.Const = src.GetConst()
+	}
+	if src.HasLt() {
+		f.Lt = src.GetLt()
+	}
+	if src.HasLte() {
+		f.Lte = src.GetLte()
+	}
+	if src.HasGt() {
+		f.Gt = src.GetGt()
+	}
+	if src.HasGte() {
+		f.Gte = src.GetGte()
+	}
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+}
+
+func (f *FieldRules) addProtovalidateInt64Rules(src *bufvalidate.Int64Rules) {
+	if src.HasConst() {
+		f.Const = src.GetConst()
+	}
+	if src.HasLt() {
+		f.Lt = src.GetLt()
+	}
+	if src.HasLte() {
+		f.Lte = src.GetLte()
+	}
+	if src.HasGt() {
+		f.Gt = src.GetGt()
+	}
+	if src.HasGte() {
+		f.Gte = src.GetGte()
+	}
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+}
+
+func (f *FieldRules) addProtovalidateUInt32Rules(src *bufvalidate.UInt32Rules) {
+	if src.HasConst() {
+		f.Const = src.GetConst()
+	}
+	if src.HasLt() {
+		f.Lt = src.GetLt()
+	}
+	if src.HasLte() {
+		f.Lte = src.GetLte()
+	}
+	if src.HasGt() {
+		f.Gt = src.GetGt()
+	}
+	if src.HasGte() {
+		f.Gte = src.GetGte()
+	}
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+}
+
+func (f *FieldRules) addProtovalidateUInt64Rules(src *bufvalidate.UInt64Rules) {
+	if src.HasConst() {
+		f.Const = src.GetConst()
+	}
+	if src.HasLt() {
+		f.Lt = src.GetLt()
+	}
+	if src.HasLte() {
+		f.Lte = src.GetLte()
+	}
+	if src.HasGt() {
+		f.Gt = src.GetGt()
+	}
+	if src.HasGte() {
+		f.Gte = src.GetGte()
+	}
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+}
+
+func (f *FieldRules) addProtovalidateSInt32Rules(src *bufvalidate.SInt32Rules) {
+	if src.HasConst() {
+		f.Const = src.GetConst()
+	}
+	if src.HasLt() {
+		f.Lt = src.GetLt()
+	}
+	if src.HasLte() {
+		f.Lte = src.GetLte()
+	}
+	if src.HasGt() {
+		f.Gt = src.GetGt()
+	}
+	if src.HasGte() {
+		f.Gte = src.GetGte()
+	}
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+}
+
+func (f *FieldRules) addProtovalidateSInt64Rules(src *bufvalidate.SInt64Rules) {
+	if src.HasConst() {
+		f.Const = src.GetConst()
+	}
+	if src.HasLt() {
+		f.Lt = src.GetLt()
+	}
+	if src.HasLte() {
+		f.Lte = src.GetLte()
+	}
+	if src.HasGt() {
+		f.Gt = src.GetGt()
+	}
+	if src.HasGte() {
+		f.Gte = src.GetGte()
+	}
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+}
+
+func (f *FieldRules) addProtovalidateFixed32Rules(src *bufvalidate.Fixed32Rules) {
+	if src.HasConst() {
+		f.Const = src.GetConst()
+	}
+	if src.HasLt() {
+		f.Lt = src.GetLt()
+	}
+	if src.HasLte() {
+		f.Lte = src.GetLte()
+	}
+	if src.HasGt() {
+		f.Gt = src.GetGt()
+	}
+	if src.HasGte() {
+		f.Gte = src.GetGte()
+	}
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+}
+
+func (f *FieldRules) addProtovalidateFixed64Rules(src *bufvalidate.Fixed64Rules) {
+	if src.HasConst() {
+		f.Const = src.GetConst()
+	}
+	if src.HasLt() {
+		f.Lt = src.GetLt()
+	}
+	if src.HasLte() {
+		f.Lte = src.GetLte()
+	}
+	if src.HasGt() {
+		f.Gt = src.GetGt()
+	}
+	if src.HasGte() {
+		f.Gte = src.GetGte()
+	}
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+}
+
+func (f *FieldRules) addProtovalidateSFixed32Rules(src *bufvalidate.SFixed32Rules) {
+	if src.HasConst() {
+		f.Const = src.GetConst()
+	}
+	if src.HasLt() {
+		f.Lt = src.GetLt()
+	}
+	if src.HasLte() {
+		f.Lte = src.GetLte()
+	}
+	if src.HasGt() {
+		f.Gt = src.GetGt()
+	}
+	if src.HasGte() {
+		f.Gte = src.GetGte()
+	}
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+}
+
+func (f *FieldRules) addProtovalidateSFixed64Rules(src *bufvalidate.SFixed64Rules) {
+	if src.HasConst() {
+		f.Const = src.GetConst()
+	}
+	if src.HasLt() {
+		f.Lt = src.GetLt()
+	}
+	if src.HasLte() {
+		f.Lte = src.GetLte()
+	}
+	if src.HasGt() {
+		f.Gt = src.GetGt()
+	}
+	if src.HasGte() {
+		f.Gte = src.GetGte()
+	}
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+}
+
+func (f *FieldRules) addProtovalidateBoolRules(src *bufvalidate.BoolRules) {
+	if src.HasConst() {
+		f.Const = src.GetConst()
+	}
+}
+
+func (f *FieldRules) addProtovalidateStringRules(src *bufvalidate.StringRules) {
+	if src.HasConst() {
+		f.Const = src.GetConst()
+	}
+	f.Len = src.GetLen()
+	f.MinLen = src.GetMinLen()
+	f.MaxLen = src.GetMaxLen()
+	f.LenBytes = src.GetLenBytes()
+	f.MinBytes = src.GetMinBytes()
+	f.MaxBytes = src.GetMaxBytes()
+	f.Pattern = src.GetPattern()
+	if src.HasPrefix() {
+		f.Prefix = src.GetPrefix()
+	}
+	if src.HasSuffix() {
+		f.Suffix = src.GetSuffix()
+	}
+	if src.HasContains() {
+		f.Contains = src.GetContains()
+	}
+	if src.HasNotContains() {
+		f.NotContains = src.GetNotContains()
+	}
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+	f.IP = src.GetIp()
+	f.IPv4 = src.GetIpv4()
+	f.IPv6 = src.GetIpv6()
+	f.IPWithPrefixLen = src.GetIpWithPrefixlen()
+	f.IPv4WithPrefixLen = src.GetIpv4WithPrefixlen()
+	f.IPv6WithPrefixLen = src.GetIpv6WithPrefixlen()
+	f.IPPrefix = src.GetIpPrefix()
+	f.IPv4Prefix = src.GetIpv4Prefix()
+	f.IPv6Prefix = src.GetIpv6Prefix()
+	f.Email = src.GetEmail()
+	f.Hostname = src.GetHostname()
+	f.HostAndPort = src.GetHostAndPort()
+	f.URI = src.GetUri()
+	f.URIRef = src.GetUriRef()
+	f.Address = src.GetAddress()
+	f.UUID = src.GetUuid()
+	f.TUUID = src.GetTuuid()
+	f.ULID = src.GetUlid()
+	f.ProtobufFQN = src.GetProtobufFqn()
+	f.ProtobufDotFQN = src.GetProtobufDotFqn()
+	if wellKnownRegex := src.GetWellKnownRegex(); wellKnownRegex != bufvalidate.KnownRegex_KNOWN_REGEX_UNSPECIFIED {
+		f.WellKnownRegex = wellKnownRegex.String()
+	}
+	if src.HasStrict() {
+		strict := src.GetStrict()
+		f.Strict = &strict
+	}
+}
+
+func (f *FieldRules) addProtovalidateBytesRules(src *bufvalidate.BytesRules) {
+	if src.HasConst() {
+		f.Const = src.GetConst()
+	}
+	f.Len = src.GetLen()
+	f.MinLen = src.GetMinLen()
+	f.MaxLen = src.GetMaxLen()
+	f.Pattern = src.GetPattern()
+	if src.HasPrefix() {
+		f.Prefix = src.GetPrefix()
+	}
+	if src.HasSuffix() {
+		f.Suffix = src.GetSuffix()
+	}
+	if src.HasContains() {
+		f.Contains = src.GetContains()
+	}
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+	f.IP = src.GetIp()
+	f.IPv4 = src.GetIpv4()
+	f.IPv6 = src.GetIpv6()
+	f.UUID = src.GetUuid()
+}
+
+func (f *FieldRules) addProtovalidateEnumRules(src *bufvalidate.EnumRules) {
+	f.DefinedOnly = src.GetDefinedOnly()
+	if src.HasConst() {
+		f.Const = src.GetConst()
+	}
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+}
+
+func (f *FieldRules) addProtovalidateAnyRules(src *bufvalidate.AnyRules) {
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+}
+
+func (f *FieldRules) addProtovalidateDurationRules(src *bufvalidate.DurationRules) {
+	if src.HasConst() {
+		f.Const = src.GetConst().AsDuration()
+	}
+	if src.HasLt() {
+		f.Lt = src.GetLt().AsDuration()
+	}
+	if src.HasLte() {
+		f.Lte = src.GetLte().AsDuration()
+	}
+	if src.HasGt() {
+		f.Gt = src.GetGt().AsDuration()
+	}
+	if src.HasGte() {
+		f.Gte = src.GetGte().AsDuration()
+	}
+	if src.GetIn() != nil {
+		in := make([]time.Duration, len(src.GetIn()))
+		for i, p := range src.GetIn() {
+			in[i] = p.AsDuration()
+		}
+		f.In = in
+	}
+	if src.GetNotIn() != nil {
+		notIn := make([]time.Duration, len(src.GetNotIn()))
+		for i, p := range src.GetNotIn() {
+			notIn[i] = p.AsDuration()
+		}
+		f.NotIn = notIn
+	}
+}
+
+func (f *FieldRules) addProtovalidateFieldMaskRules(src *bufvalidate.FieldMaskRules) {
+	if src.HasConst() {
+		f.Const = src.GetConst().GetPaths()
+	}
+	if in := src.GetIn(); in != nil {
+		f.In = in
+	}
+	if notIn := src.GetNotIn(); notIn != nil {
+		f.NotIn = notIn
+	}
+}
+
+func (f *FieldRules) addProtovalidateTimestampRules(src *bufvalidate.TimestampRules) {
+	if src.HasConst() {
+		f.Const = src.GetConst().AsTime()
+	}
+	if src.HasLt() {
+		f.Lt = src.GetLt().AsTime()
+	}
+	if src.HasLte() {
+		f.Lte = src.GetLte().AsTime()
+	}
+	if src.HasGt() {
+		f.Gt = src.GetGt().AsTime()
+	}
+	if src.HasGte() {
+		f.Gte = src.GetGte().AsTime()
+	}
+	f.LtNow = src.GetLtNow()
+	f.GtNow = src.GetGtNow()
+	if src.HasWithin() {
+		f.Within = src.GetWithin().AsDuration()
+	}
+}
+
+func (f *FieldRules) AddProtovalidateRules(src *bufvalidate.FieldRules, t PGSFieldType) {
+	if src == nil {
+		return
+	}
+	f.Required = f.Required || src.GetRequired()
+	switch src.GetIgnore() {
+	case bufvalidate.Ignore_IGNORE_IF_ZERO_VALUE:
+		f.IgnoreEmpty = true
+	case bufvalidate.Ignore_IGNORE_ALWAYS:
+		f.Skip = true
+	}
+	f.CEL = append(f.CEL, buildCELRules(src.GetCel(), src.GetCelExpression())...)
+	if rules := src.GetFloat(); rules != nil {
+		f.addProtovalidateFloatRules(rules)
+	}
+	if rules := src.GetDouble(); rules != nil {
+		f.addProtovalidateDoubleRules(rules)
+	}
+	if rules := src.GetInt32(); rules != nil {
+		f.addProtovalidateInt32Rules(rules)
+	}
+	if rules := src.GetInt64(); rules != nil {
+		f.addProtovalidateInt64Rules(rules)
+	}
+	if rules := src.GetUint32(); rules != nil {
+		f.addProtovalidateUInt32Rules(rules)
+	}
+	if rules := src.GetUint64(); rules != nil {
+		f.addProtovalidateUInt64Rules(rules)
+	}
+	if rules := src.GetSint32(); rules != nil {
+		f.addProtovalidateSInt32Rules(rules)
+	}
+	if rules := src.GetSint64(); rules != nil {
+		f.addProtovalidateSInt64Rules(rules)
+	}
+	if rules := src.GetFixed32(); rules != nil {
+		f.addProtovalidateFixed32Rules(rules)
+	}
+	if rules := src.GetFixed64(); rules != nil {
+		f.addProtovalidateFixed64Rules(rules)
+	}
+	if rules := src.GetSfixed32(); rules != nil {
+		f.addProtovalidateSFixed32Rules(rules)
+	}
+	if rules := src.GetSfixed64(); rules != nil {
+		f.addProtovalidateSFixed64Rules(rules)
+	}
+	if rules := src.GetBool(); rules != nil {
+		f.addProtovalidateBoolRules(rules)
+	}
+	if rules := src.GetString(); rules != nil {
+		f.addProtovalidateStringRules(rules)
+	}
+	if rules := src.GetBytes(); rules != nil {
+		f.addProtovalidateBytesRules(rules)
+	}
+	if rules := src.GetEnum(); rules != nil && t.IsEnum() {
+		f.addProtovalidateEnumRules(rules)
+	}
+	if rules := src.GetAny(); rules != nil {
+		f.addProtovalidateAnyRules(rules)
+	}
+	if rules := src.GetDuration(); rules != nil {
+		f.addProtovalidateDurationRules(rules)
+	}
+	if rules := src.GetFieldMask(); rules != nil {
+		f.addProtovalidateFieldMaskRules(rules)
+	}
+	if rules := src.GetTimestamp(); rules != nil {
+		f.addProtovalidateTimestampRules(rules)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package gendatafiles
+
+import "google.golang.org/genproto/googleapis/api/annotations"
+
+type Resource struct {
+	Type      string   `json:"type" yaml:"type"`
+	Patterns  []string `json:"patterns,omitempty" yaml:"patterns,omitempty"`
+	NameField string   `json:"name_field,omitempty" yaml:"name_field,omitempty"`
+	Plural    string   `json:"plural,omitempty" yaml:"plural,omitempty"`
+	Singular  string   `json:"singular,omitempty" yaml:"singular,omitempty"`
+	History   string   `json:"history,omitempty" yaml:"history,omitempty"`
+	Style     []string `json:"style,omitempty" yaml:"style,omitempty"`
+}
+
+func (m *Message) AddResource(src *annotations.ResourceDescriptor) {
+	if src == nil {
+		return
+	}
+	resource := &Resource{
+		Type:      src.GetType(),
+		Patterns:  src.GetPattern(),
+		NameField: src.GetNameField(),
+		Plural:    src.GetPlural(),
+		Singular:  src.GetSingular(),
+	}
+	if history := src.GetHistory(); history != annotations.ResourceDescriptor_HISTORY_UNSPECIFIED {
+		resource.History = history.String()
+	}
+	for _, style := range src.GetStyle() {
+		resource.Style = append(resource.Style, style.String())
+	}
+	m.Resource = resource
+}
+
+type ResourceReference struct {
+	Type      string `json:"type,omitempty" yaml:"type,omitempty"`
+	ChildType string `json:"child_type,omitempty" yaml:"child_type,omitempty"`
+}
+
+func (f *Field) AddResourceReference(src *annotations.ResourceReference) {
+	if src == nil {
+		return
+	}
+	f.ResourceReference = &ResourceReference{
+		Type:      src.GetType(),
+		ChildType: src.GetChildType(),
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package gendatafiles
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	pgs "github.com/lyft/protoc-gen-star"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ErrInvalidRules is wrapped by all errors that report validation rules that
+// do not match the field type or that contradict each other.
+var ErrInvalidRules = errors.New("invalid rules")
+
+// ruleType returns the name of the type-specific rules that are set in src,
+// which is either a validate.FieldRules or a buf.validate.FieldRules.
+func ruleType(src protoreflect.ProtoMessage) string {
+	if src == nil {
+		return ""
+	}
+	msg := src.ProtoReflect()
+	if !msg.IsValid() {
+		return ""
+	}
+	oneof := msg.Descriptor().Oneofs().ByName("type")
+	if oneof == nil {
+		return ""
+	}
+	if fd := msg.WhichOneof(oneof); fd != nil {
+		return string(fd.Name())
+	}
+	return ""
+}
+
+func hasMessageRules(src protoreflect.ProtoMessage) bool {
+	if src == nil {
+		return false
+	}
+	msg := src.ProtoReflect()
+	if !msg.IsValid() {
+		return false
+	}
+	fd := msg.Descriptor().Fields().ByName("message")
+	return fd != nil && msg.Has(fd)
+}
+
+func wrapperType(src pgs.Message) string {
+	if !src.IsWellKnown() {
+		return ""
+	}
+	name := src.WellKnownType().Name().String()
+	if !strings.HasSuffix(name, "Value") || name == "Value" || name == "ListValue" {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSuffix(name, "Value"))
+}
+
+func describeType(t PGSFieldType) string {
+	switch {
+	case t.IsEnum():
+		return "enum " + strings.TrimPrefix(t.Enum().FullyQualifiedName(), ".")
+	case t.IsEmbed():
+		return "message " + strings.TrimPrefix(t.Embed().FullyQualifiedName(), ".")
+	default:
+		return ProtoTypeString(t.ProtoType())
+	}
+}
+
+func checkRuleType(src protoreflect.ProtoMessage, t PGSFieldType) error {
+	if hasMessageRules(src) && !t.IsEmbed() {
+		return fmt.Errorf("%w: message rules on %s field", ErrInvalidRules, describeType(t))
+	}
+	typ := ruleType(src)
+	var ok bool
+	switch typ {
+	case "":
+		return nil
+	case "enum":
+		ok = t.IsEnum()
+	case "any":
+		ok = t.IsEmbed() && t.Embed().WellKnownType() == pgs.AnyWKT
+	case "duration":
+		ok = t.IsEmbed() && t.Embed().WellKnownType() == pgs.DurationWKT
+	case "timestamp":
+		ok = t.IsEmbed() && t.Embed().WellKnownType() == pgs.TimestampWKT
+	case "field_mask":
+		ok = t.IsEmbed() && t.Embed().FullyQualifiedName() == ".google.protobuf.FieldMask"
+	case "repeated", "map":
+		ok = false
+	default:
+		switch {
+		case t.IsEnum():
+			ok = false
+		case t.IsEmbed():
+			ok = wrapperType(t.Embed()) == typ
+		default:
+			ok = ProtoTypeString(t.ProtoType()) == typ
+		}
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s rules on %s field", ErrInvalidRules, typ, describeType(t))
+	}
+	return nil
+}
+
+func (f *Field) addRulesError(prefix string, err error) {
+	if err == nil {
+		return
+	}
+	if prefix != "" {
+		err = fmt.Errorf("%s: %w", prefix, err)
+	}
+	f.addError(err)
+}
+
+// checkRuleTypes reports type-specific rules in src, items, keys and values
+// that can not be applied to the type of the field.
+func (f *Field) checkRuleTypes(src, items, keys, values protoreflect.ProtoMessage) {
+	fieldType := f.src.Type()
+	switch {
+	case fieldType.IsRepeated():
+		if typ := ruleType(src); typ != "" && typ != "repeated" {
+			f.addRulesError("", fmt.Errorf("%w: %s rules on repeated field", ErrInvalidRules, typ))
+		}
+		f.addRulesError("items", checkRuleType(items, fieldType.Element()))
+	case fieldType.IsMap():
+		if typ := ruleType(src); typ != "" && typ != "map" {
+			f.addRulesError("", fmt.Errorf("%w: %s rules on map field", ErrInvalidRules, typ))
+		}
+		f.addRulesError("keys", checkRuleType(keys, fieldType.Key()))
+		f.addRulesError("values", checkRuleType(values, fieldType.Element()))
+	default:
+		f.addRulesError("", checkRuleType(src, fieldType))
+	}
+}
+
+// withoutRules returns a copy of the field type without rules.
+func (t FieldType) withoutRules() FieldType {
+	t.Rules = FieldRules{}
+	for _, elem := range []**FieldTypeElem{&t.Repeated, &t.MapKey, &t.MapValue} {
+		if *elem != nil {
+			e := **elem
+			e.Rules = FieldRules{}
+			*elem = &e
+		}
+	}
+	return t
+}
+
+// checkRuleConflicts reports rules that can never be satisfied. The rules are
+// added by add to a copy of the field without rules, so that rules of
+// protoc-gen-validate and protovalidate are checked separately.
+func (f *Field) checkRuleConflicts(add func(*Field)) {
+	rules := Field{src: f.src, Entity: Entity{src: f.Entity.src}, FieldType: f.FieldType.withoutRules()}
+	add(&rules)
+	for _, err := range rules.Rules.Conflicts() {
+		f.addRulesError("", err)
+	}
+	if rules.Repeated != nil {
+		for _, err := range rules.Repeated.Rules.Conflicts() {
+			f.addRulesError("items", err)
+		}
+	}
+	if rules.MapKey != nil {
+		for _, err := range rules.MapKey.Rules.Conflicts() {
+			f.addRulesError("keys", err)
+		}
+	}
+	if rules.MapValue != nil {
+		for _, err := range rules.MapValue.Rules.Conflicts() {
+			f.addRulesError("values", err)
+		}
+	}
+}
+
+// RuleValue dereferences rule values that are stored as pointers to the
+// value in the source rules.
+func RuleValue(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr {
+		return v
+	}
+	if rv.IsNil() {
+		return nil
+	}
+	return rv.Elem().Interface()
+}
+
+// compareRuleValues compares two rule values of the same type. It returns
+// false if the values can not be compared.
+func compareRuleValues(a, b interface{}) (int, bool) {
+	a, b = RuleValue(a), RuleValue(b)
+	if a, ok := a.(time.Time); ok {
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b), true
+		}
+		return 0, false
+	}
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !av.IsValid() || !bv.IsValid() || av.Type() != bv.Type() {
+		return 0, false
+	}
+	switch av.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareOrdered(av.Int(), bv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return compareOrdered(av.Uint(), bv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return compareOrdered(av.Float(), bv.Float()), true
+	default:
+		return 0, false
+	}
+}
+
+func compareOrdered[T int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func ruleValuesContain(values, value interface{}) bool {
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice {
+		return false
+	}
+	for i := 0; i < v.Len(); i++ {
+		if reflect.DeepEqual(v.Index(i).Interface(), RuleValue(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkLengths(exactName, name string, exact, min, max uint64) []error {
+	var errs []error
+	if max != 0 && min > max {
+		errs = append(errs, fmt.Errorf("%w: min_%s %d > max_%s %d", ErrInvalidRules, name, min, name, max))
+	}
+	if exact != 0 && exact < min {
+		errs = append(errs, fmt.Errorf("%w: %s %d < min_%s %d", ErrInvalidRules, exactName, exact, name, min))
+	}
+	if exact != 0 && max != 0 && exact > max {
+		errs = append(errs, fmt.Errorf("%w: %s %d > max_%s %d", ErrInvalidRules, exactName, exact, name, max))
+	}
+	return errs
+}
+
+// Conflicts returns the rules that contradict each other, so that no value
+// can satisfy them.
+//
+// A lower bound that is greater than the upper bound is not a conflict; it
+// requests an exclusive range, where the value must lie outside the bounds.
+func (f FieldRules) Conflicts() []error {
+	var errs []error
+	errs = append(errs, checkLengths("len", "len", f.Len, f.MinLen, f.MaxLen)...)
+	errs = append(errs, checkLengths("len_bytes", "bytes", f.LenBytes, f.MinBytes, f.MaxBytes)...)
+	errs = append(errs, checkLengths("", "items", 0, f.MinItems, f.MaxItems)...)
+	errs = append(errs, checkLengths("", "pairs", 0, f.MinPairs, f.MaxPairs)...)
+
+	lowerName, lower := "gte", f.Gte
+	if f.Gt != nil {
+		lowerName, lower = "gt", f.Gt
+	}
+	upperName, upper := "lte", f.Lte
+	if f.Lt != nil {
+		upperName, upper = "lt", f.Lt
+	}
+	if lower != nil && upper != nil {
+		if c, ok := compareRuleValues(lower, upper); ok && c == 0 && (lowerName == "gt" || upperName == "lt") {
+			errs = append(errs, fmt.Errorf("%w: %s %v >= %s %v", ErrInvalidRules, lowerName, RuleValue(lower), upperName, RuleValue(upper)))
+		}
+	}
+	if f.LtNow && f.GtNow {
+		errs = append(errs, fmt.Errorf("%w: lt_now and gt_now", ErrInvalidRules))
+	}
+
+	if f.Const != nil {
+		if f.In != nil && !ruleValuesContain(f.In, f.Const) {
+			errs = append(errs, fmt.Errorf("%w: const %v not in %v", ErrInvalidRules, RuleValue(f.Const), f.In))
+		}
+		if f.NotIn != nil && ruleValuesContain(f.NotIn, f.Const) {
+			errs = append(errs, fmt.Errorf("%w: const %v in not_in %v", ErrInvalidRules, RuleValue(f.Const), f.NotIn))
+		}
+	}
+	return errs
+}
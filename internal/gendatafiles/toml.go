@@ -0,0 +1,345 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package gendatafiles
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tomlValue converts v into a value that can be written as TOML. Tables become
+// a MapSlice, arrays a []interface{}, and scalars a string, bool, int64,
+// float32, float64 or time.Time. Since TOML has no null, it returns false for
+// values that should be left out.
+//
+// Structs are converted using their yaml tags, so that the TOML files have the
+// same structure as the YAML files.
+func tomlValue(v reflect.Value) (interface{}, bool, error) {
+	if !v.IsValid() {
+		return nil, false, nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, false, nil
+		}
+		return tomlValue(v.Elem())
+	}
+	switch value := v.Interface().(type) {
+	case MapSlice:
+		table := make(MapSlice, 0, len(value))
+		for _, item := range value {
+			itemValue, ok, err := tomlValue(reflect.ValueOf(item.Value))
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				table = append(table, MapItem{Key: item.Key, Value: itemValue})
+			}
+		}
+		return table, true, nil
+	case Bytes:
+		return base64.StdEncoding.EncodeToString(value), true, nil
+	case time.Duration:
+		return value.String(), true, nil
+	case time.Time:
+		return value, true, nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			// TOML integers are 64 bit signed integers.
+			return strconv.FormatUint(v.Uint(), 10), true, nil
+		}
+		return int64(v.Uint()), true, nil
+	case reflect.Float32:
+		return float32(v.Float()), true, nil
+	case reflect.Float64:
+		return v.Float(), true, nil
+	case reflect.String:
+		return v.String(), true, nil
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(v.Bytes()), true, nil
+		}
+		array := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, ok, err := tomlValue(v.Index(i))
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				array = append(array, elem)
+			}
+		}
+		return array, true, nil
+	case reflect.Map:
+		table := make(MapSlice, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			value, ok, err := tomlValue(iter.Value())
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				table = append(table, MapItem{Key: fmt.Sprint(iter.Key().Interface()), Value: value})
+			}
+		}
+		sort.Sort(mapSliceByKey(table))
+		return table, true, nil
+	case reflect.Struct:
+		return tomlStruct(v)
+	default:
+		return nil, false, fmt.Errorf("toml: unsupported type %s", v.Type())
+	}
+}
+
+func tomlStruct(v reflect.Value) (MapSlice, bool, error) {
+	var table MapSlice
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		name, flags := tag[0], tag[1:]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		var inline, omitEmpty bool
+		for _, flag := range flags {
+			switch flag {
+			case "inline":
+				inline = true
+			case "omitempty":
+				omitEmpty = true
+			}
+		}
+		if inline {
+			inlined, _, err := tomlStruct(v.Field(i))
+			if err != nil {
+				return nil, false, err
+			}
+			table = append(table, inlined...)
+			continue
+		}
+		if omitEmpty && tomlIsZero(v.Field(i)) {
+			continue
+		}
+		value, ok, err := tomlValue(v.Field(i))
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", name, err)
+		}
+		if ok {
+			table = append(table, MapItem{Key: name, Value: value})
+		}
+	}
+	return table, true, nil
+}
+
+func tomlIsZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	case reflect.Struct:
+		if _, ok := v.Interface().(time.Time); ok {
+			return v.IsZero()
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" && !tomlIsZero(v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return v.IsZero()
+	}
+}
+
+func isTOMLTableArray(v interface{}) bool {
+	array, ok := v.([]interface{})
+	if !ok || len(array) == 0 {
+		return false
+	}
+	for _, elem := range array {
+		if _, ok := elem.(MapSlice); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+type tomlWriter struct {
+	strings.Builder
+}
+
+func (w *tomlWriter) writeKey(key string) {
+	for _, r := range key {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			w.writeString(key)
+			return
+		}
+	}
+	if key == "" {
+		w.writeString(key)
+		return
+	}
+	w.WriteString(key)
+}
+
+func (w *tomlWriter) writeString(s string) {
+	w.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			w.WriteString(`\"`)
+		case '\\':
+			w.WriteString(`\\`)
+		case '\b':
+			w.WriteString(`\b`)
+		case '\t':
+			w.WriteString(`\t`)
+		case '\n':
+			w.WriteString(`\n`)
+		case '\f':
+			w.WriteString(`\f`)
+		case '\r':
+			w.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(w, `\u%04X`, r)
+			} else {
+				w.WriteRune(r)
+			}
+		}
+	}
+	w.WriteByte('"')
+}
+
+func (w *tomlWriter) writeFloat(f float64, bitSize int) {
+	switch {
+	case math.IsNaN(f):
+		w.WriteString("nan")
+	case math.IsInf(f, 1):
+		w.WriteString("inf")
+	case math.IsInf(f, -1):
+		w.WriteString("-inf")
+	default:
+		s := strconv.FormatFloat(f, 'g', -1, bitSize)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		w.WriteString(s)
+	}
+}
+
+func (w *tomlWriter) writeValue(v interface{}) {
+	switch v := v.(type) {
+	case MapSlice:
+		w.WriteByte('{')
+		for i, item := range v {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteByte(' ')
+			w.writeKey(item.Key)
+			w.WriteString(" = ")
+			w.writeValue(item.Value)
+		}
+		if len(v) > 0 {
+			w.WriteByte(' ')
+		}
+		w.WriteByte('}')
+	case []interface{}:
+		w.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			w.writeValue(elem)
+		}
+		w.WriteByte(']')
+	case string:
+		w.writeString(v)
+	case bool:
+		w.WriteString(strconv.FormatBool(v))
+	case int64:
+		w.WriteString(strconv.FormatInt(v, 10))
+	case float32:
+		w.writeFloat(float64(v), 32)
+	case float64:
+		w.writeFloat(v, 64)
+	case time.Time:
+		w.WriteString(v.Format(time.RFC3339Nano))
+	}
+}
+
+func (w *tomlWriter) writeHeader(open string, path []string, close string) {
+	if w.Len() > 0 {
+		w.WriteByte('\n')
+	}
+	w.WriteString(open)
+	for i, key := range path {
+		if i > 0 {
+			w.WriteByte('.')
+		}
+		w.writeKey(key)
+	}
+	w.WriteString(close)
+	w.WriteByte('\n')
+}
+
+// writeTable writes the key/value pairs of table, followed by its tables and
+// arrays of tables, which must come after the key/value pairs in TOML.
+func (w *tomlWriter) writeTable(path []string, table MapSlice) {
+	for _, item := range table {
+		if _, ok := item.Value.(MapSlice); ok || isTOMLTableArray(item.Value) {
+			continue
+		}
+		w.writeKey(item.Key)
+		w.WriteString(" = ")
+		w.writeValue(item.Value)
+		w.WriteByte('\n')
+	}
+	for _, item := range table {
+		itemPath := append(path[:len(path):len(path)], item.Key)
+		if value, ok := item.Value.(MapSlice); ok {
+			w.writeHeader("[", itemPath, "]")
+			w.writeTable(itemPath, value)
+		}
+		if isTOMLTableArray(item.Value) {
+			for _, elem := range item.Value.([]interface{}) {
+				w.writeHeader("[[", itemPath, "]]")
+				w.writeTable(itemPath, elem.(MapSlice))
+			}
+		}
+	}
+}
+
+func marshalTOML(v interface{}) ([]byte, error) {
+	value, _, err := tomlValue(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	table, ok := value.(MapSlice)
+	if !ok {
+		return nil, fmt.Errorf("toml: can not encode %T as a table", v)
+	}
+	var w tomlWriter
+	w.writeTable(nil, table)
+	return []byte(w.String()), nil
+}
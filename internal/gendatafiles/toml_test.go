@@ -0,0 +1,155 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package gendatafiles
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMarshalTOML(t *testing.T) {
+	lt := int32(5)
+	for _, tt := range []struct {
+		name     string
+		value    interface{}
+		expected string
+	}{
+		{
+			name: "order",
+			value: MapSlice{
+				{Key: "b", Value: 1},
+				{Key: "a", Value: 2},
+				{Key: "omitted", Value: nil},
+				{Key: "c", Value: 3},
+			},
+			expected: "b = 1\na = 2\nc = 3\n",
+		},
+		{
+			name: "tables",
+			value: MapSlice{
+				{Key: "name", Value: "x"},
+				{Key: "sub", Value: MapSlice{
+					{Key: "k", Value: 1},
+					{Key: "empty", Value: MapSlice{}},
+				}},
+				{Key: "after", Value: true},
+			},
+			expected: "name = \"x\"\nafter = true\n\n[sub]\nk = 1\n\n[sub.empty]\n",
+		},
+		{
+			name: "arrays of tables",
+			value: MapSlice{
+				{Key: "items", Value: []interface{}{
+					MapSlice{
+						{Key: "id", Value: 1},
+						{Key: "meta", Value: MapSlice{{Key: "k", Value: "v"}}},
+						{Key: "tags", Value: []interface{}{MapSlice{{Key: "t", Value: "a"}}}},
+					},
+					MapSlice{{Key: "id", Value: 2}},
+				}},
+				{Key: "empty", Value: []interface{}{}},
+				{Key: "mixed", Value: []interface{}{1, MapSlice{{Key: "k", Value: 1}}}},
+			},
+			expected: "empty = []\nmixed = [1, { k = 1 }]\n" +
+				"\n[[items]]\nid = 1\n" +
+				"\n[items.meta]\nk = \"v\"\n" +
+				"\n[[items.tags]]\nt = \"a\"\n" +
+				"\n[[items]]\nid = 2\n",
+		},
+		{
+			name: "quoted keys",
+			value: MapSlice{
+				{Key: "bare_key-1", Value: 1},
+				{Key: "acme.x", Value: 2},
+				{Key: "a b", Value: 3},
+				{Key: "", Value: 4},
+				{Key: "acme.y", Value: MapSlice{{Key: "z", Value: 5}}},
+			},
+			expected: "bare_key-1 = 1\n\"acme.x\" = 2\n\"a b\" = 3\n\"\" = 4\n\n[\"acme.y\"]\nz = 5\n",
+		},
+		{
+			name: "integers",
+			value: MapSlice{
+				{Key: "int", Value: -1},
+				{Key: "uint", Value: uint64(math.MaxInt64)},
+				{Key: "big", Value: uint64(math.MaxUint64)},
+			},
+			expected: "int = -1\nuint = 9223372036854775807\nbig = \"18446744073709551615\"\n",
+		},
+		{
+			name: "floats",
+			value: MapSlice{
+				{Key: "nan", Value: math.NaN()},
+				{Key: "inf", Value: math.Inf(1)},
+				{Key: "neg_inf", Value: math.Inf(-1)},
+				{Key: "whole", Value: float64(2)},
+				{Key: "float32", Value: float32(0.1)},
+				{Key: "exp", Value: 1e21},
+			},
+			expected: "nan = nan\ninf = inf\nneg_inf = -inf\nwhole = 2.0\nfloat32 = 0.1\nexp = 1e+21\n",
+		},
+		{
+			name: "strings",
+			value: MapSlice{
+				{Key: "s", Value: "a\"b\\c\n\t\r\b\f\x01\x7fé"},
+			},
+			expected: "s = \"a\\\"b\\\\c\\n\\t\\r\\b\\f\\u0001\\u007Fé\"\n",
+		},
+		{
+			name: "bytes and durations",
+			value: MapSlice{
+				{Key: "bytes", Value: Bytes("hi")},
+				{Key: "raw", Value: []byte("hi")},
+				{Key: "duration", Value: 90 * time.Second},
+				{Key: "time", Value: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)},
+			},
+			expected: "bytes = \"aGk=\"\nraw = \"aGk=\"\nduration = \"1m30s\"\ntime = 2021-01-02T03:04:05Z\n",
+		},
+		{
+			name: "sorted map keys",
+			value: MapSlice{
+				{Key: "m", Value: map[string]int{"c": 3, "a": 1, "b": 2}},
+			},
+			expected: "[m]\na = 1\nb = 2\nc = 3\n",
+		},
+		{
+			name: "rules",
+			value: FieldRules{
+				MinLen: 3,
+				Prefix: Bytes("ab"),
+				Lt:     &lt,
+				In:     []string{"a", "b"},
+				Within: time.Second,
+			},
+			expected: "min_len = 3\nprefix = \"YWI=\"\nwithin = \"1s\"\nlt = 5\nin = [\"a\", \"b\"]\n",
+		},
+		{
+			name: "inline structs",
+			value: EnumValue{
+				Entity: Entity{Name: "A", Comment: "The A."},
+				Value:  1,
+			},
+			expected: "name = \"A\"\ncomment = \"The A.\"\nvalue = 1\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 2; i++ {
+				b, err := marshalTOML(tt.value)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if string(b) != tt.expected {
+					t.Fatalf("expected:\n%s\ngot:\n%s", tt.expected, b)
+				}
+			}
+		})
+	}
+}
+
+func TestMarshalTOMLNotATable(t *testing.T) {
+	if _, err := marshalTOML([]string{"a"}); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package genhtml
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path"
+
+	pgs "github.com/lyft/protoc-gen-star"
+	"htdvisser.dev/protoc-gen-collection/internal/gendatafiles"
+)
+
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+//go:embed static/*
+var staticFiles embed.FS
+
+var templates = make(map[string]*template.Template)
+
+func init() {
+	layout := template.Must(template.ParseFS(templateFiles, "templates/layout.html"))
+	for _, name := range []string{"index", "package", "message", "enum", "service"} {
+		templates[name] = template.Must(template.Must(layout.Clone()).ParseFS(templateFiles, "templates/"+name+".html"))
+	}
+}
+
+type HTMLModule struct {
+	*pgs.ModuleBase
+}
+
+func HTML() *HTMLModule {
+	return &HTMLModule{
+		ModuleBase: &pgs.ModuleBase{},
+	}
+}
+
+func (m *HTMLModule) Name() string { return "html" }
+
+func (m *HTMLModule) Execute(targets map[string]pgs.File, packages map[string]pgs.Package) []pgs.Artifact {
+	site := BuildSite(packages)
+	if len(site.Packages) == 0 {
+		return m.Artifacts()
+	}
+	m.render("index.html", "index", &Page{site: site, Title: "API Reference"})
+	for _, pkg := range site.Packages {
+		page := func(title string, data interface{}) *Page {
+			return &Page{site: site, Root: "../", Package: pkg.Name, Title: title, Data: data}
+		}
+		m.render(path.Join(pkg.Name.String(), "index.html"), "package", page(pkg.Name.String(), pkg))
+		for _, service := range pkg.Services {
+			m.render(site.href(pkg.Name, service.Name), "service", page(service.Name.String(), service))
+		}
+		for _, message := range pkg.Messages {
+			m.render(site.href(pkg.Name, message.Name), "message", page(message.Name.String(), message))
+		}
+		for _, enum := range pkg.Enums {
+			m.render(site.href(pkg.Name, enum.Name), "enum", page(enum.Name.String(), enum))
+		}
+	}
+	index, err := json.Marshal(site.SearchIndex())
+	if err != nil {
+		m.AddError(err.Error())
+	} else {
+		m.writeFile("search-index.js", fmt.Sprintf("window.searchIndex = %s;\n", index))
+	}
+	for _, name := range []string{"style.css", "search.js"} {
+		content, err := staticFiles.ReadFile("static/" + name)
+		m.CheckErr(err)
+		m.writeFile(name, string(content))
+	}
+	return m.Artifacts()
+}
+
+func (m *HTMLModule) writeFile(name, content string) {
+	m.OverwriteCustomFile(m.JoinPath("html", name), content, 0644)
+}
+
+func (m *HTMLModule) render(name, templateName string, page *Page) {
+	var buf bytes.Buffer
+	if err := templates[templateName].ExecuteTemplate(&buf, "layout.html", page); err != nil {
+		m.AddError(err.Error())
+		return
+	}
+	m.writeFile(name, buf.String())
+}
+
+// Page is the data of a page of the site. Its methods are used by the
+// templates to render links and values.
+type Page struct {
+	site    *Site
+	Root    string
+	Package pgs.Name
+	Title   string
+	Data    interface{}
+}
+
+func (p *Page) Site() *Site { return p.site }
+
+// Href returns the relative link from the page to a page of the site.
+func (p *Page) Href(pkg, name pgs.Name) string {
+	return p.Root + p.site.href(pkg, name)
+}
+
+// TypeLink returns a link to the page of the referenced message or enum, or
+// only its name if the site does not have a page for it.
+func (p *Page) TypeLink(v interface{}) template.HTML {
+	var ref gendatafiles.Ref
+	switch v := v.(type) {
+	case gendatafiles.Ref:
+		ref = v
+	case *gendatafiles.Ref:
+		if v == nil {
+			return ""
+		}
+		ref = *v
+	default:
+		return ""
+	}
+	pkg := ref.PackageName()
+	label := ref.Name.String()
+	if pkg != p.Package {
+		label = pkg.String() + "." + label
+	}
+	if !p.site.pages[p.site.href(pkg, ref.Name)] {
+		return template.HTML(fmt.Sprintf("<code>%s</code>", template.HTMLEscapeString(label)))
+	}
+	return template.HTML(fmt.Sprintf(`<a href="%s"><code>%s</code></a>`,
+		template.HTMLEscapeString(p.Href(pkg, ref.Name)), template.HTMLEscapeString(label)))
+}
+
+func (p *Page) elemType(elem gendatafiles.FieldTypeElem) string {
+	switch {
+	case elem.Enum.Name != "":
+		return string(p.TypeLink(elem.Enum))
+	case elem.Message.Name != "":
+		return string(p.TypeLink(elem.Message))
+	default:
+		return fmt.Sprintf("<code>%s</code>", template.HTMLEscapeString(elem.Type))
+	}
+}
+
+// FieldType returns the type of a field, linking to the pages of its messages
+// and enums.
+func (p *Page) FieldType(fieldType gendatafiles.FieldType) template.HTML {
+	return template.HTML(fieldType.Format(p.elemType, template.HTMLEscapeString))
+}
+
+// Methods returns the methods of a service.
+func (p *Page) Methods(service gendatafiles.Service) []gendatafiles.Method {
+	methods := make([]gendatafiles.Method, len(service.Methods))
+	for i, item := range service.Methods {
+		methods[i] = item.Value.(gendatafiles.Method)
+	}
+	return methods
+}
+
+// Body returns the request body of an HTTP rule.
+func (p *Page) Body(rule gendatafiles.HTTPRule) string {
+	if rule.BodyMode == "message" {
+		return "*"
+	}
+	return rule.Input
+}
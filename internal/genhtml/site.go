@@ -0,0 +1,101 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package genhtml
+
+import (
+	"path"
+	"sort"
+
+	pgs "github.com/lyft/protoc-gen-star"
+	"htdvisser.dev/protoc-gen-collection/internal/gendatafiles"
+)
+
+// Site is the documentation of all packages with build targets.
+type Site struct {
+	Packages []gendatafiles.Package
+	pages    map[string]bool
+}
+
+func BuildSite(packages map[string]pgs.Package) *Site {
+	site := &Site{pages: make(map[string]bool)}
+	for _, src := range packages {
+		pkg := gendatafiles.BuildPackage(src)
+		if pkg.IsEmpty() {
+			continue
+		}
+		for _, service := range pkg.Services {
+			site.pages[site.href(pkg.Name, service.Name)] = true
+		}
+		for _, message := range pkg.Messages {
+			site.pages[site.href(pkg.Name, message.Name)] = true
+		}
+		for _, enum := range pkg.Enums {
+			site.pages[site.href(pkg.Name, enum.Name)] = true
+		}
+		site.Packages = append(site.Packages, pkg)
+	}
+	sort.Slice(site.Packages, func(i, j int) bool {
+		return site.Packages[i].Name < site.Packages[j].Name
+	})
+	return site
+}
+
+func (s *Site) href(pkg, name pgs.Name) string {
+	return path.Join(pkg.String(), name.String()+".html")
+}
+
+// SearchEntry is an entry of the search index.
+type SearchEntry struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`
+	Package string `json:"package"`
+	Href    string `json:"href"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// SearchIndex returns the entries of the search index, with links that are
+// relative to the root of the site.
+func (s *Site) SearchIndex() []SearchEntry {
+	var index []SearchEntry
+	add := func(kind string, pkg pgs.Name, name, href string, entity gendatafiles.Entity) {
+		index = append(index, SearchEntry{
+			Name:    name,
+			Kind:    kind,
+			Package: pkg.String(),
+			Href:    href,
+			Comment: entity.Comment,
+		})
+	}
+	for _, pkg := range s.Packages {
+		index = append(index, SearchEntry{
+			Name:    pkg.Name.String(),
+			Kind:    "package",
+			Package: pkg.Name.String(),
+			Href:    path.Join(pkg.Name.String(), "index.html"),
+		})
+		for _, service := range pkg.Services {
+			href := s.href(pkg.Name, service.Name)
+			add("service", pkg.Name, service.Name.String(), href, service.Entity)
+			for _, item := range service.Methods {
+				method := item.Value.(gendatafiles.Method)
+				add("method", pkg.Name, service.Name.String()+"."+method.Name.String(), href+"#method-"+method.Name.String(), method.Entity)
+			}
+		}
+		for _, message := range pkg.Messages {
+			href := s.href(pkg.Name, message.Name)
+			add("message", pkg.Name, message.Name.String(), href, message.Entity)
+			for _, field := range message.Fields {
+				add("field", pkg.Name, message.Name.String()+"."+field.Name.String(), href+"#field-"+field.Name.String(), field.Entity)
+			}
+		}
+		for _, enum := range pkg.Enums {
+			href := s.href(pkg.Name, enum.Name)
+			add("enum", pkg.Name, enum.Name.String(), href, enum.Entity)
+			for _, value := range enum.Values {
+				add("value", pkg.Name, enum.Name.String()+"."+value.Name.String(), href+"#value-"+value.Name.String(), value.Entity)
+			}
+		}
+	}
+	return index
+}
@@ -0,0 +1,54 @@
+(function () {
+  var input = document.getElementById("search");
+  var results = document.getElementById("search-results");
+  var tree = document.getElementById("tree");
+  var root = document.body.dataset.root || "";
+  var index = window.searchIndex || [];
+  var limit = 50;
+
+  function search(query) {
+    var byName = [];
+    var byComment = [];
+    for (var i = 0; i < index.length; i++) {
+      var entry = index[i];
+      if (entry.name.toLowerCase().indexOf(query) >= 0) {
+        byName.push(entry);
+      } else if (entry.comment && entry.comment.toLowerCase().indexOf(query) >= 0) {
+        byComment.push(entry);
+      }
+    }
+    return byName.concat(byComment).slice(0, limit);
+  }
+
+  function render(entries) {
+    results.textContent = "";
+    if (entries.length === 0) {
+      var empty = document.createElement("li");
+      empty.textContent = "No results";
+      results.appendChild(empty);
+      return;
+    }
+    entries.forEach(function (entry) {
+      var item = document.createElement("li");
+      var link = document.createElement("a");
+      link.href = root + entry.href;
+      link.textContent = entry.name;
+      link.title = entry.package;
+      var kind = document.createElement("span");
+      kind.className = "kind";
+      kind.textContent = entry.kind;
+      item.appendChild(link);
+      item.appendChild(kind);
+      results.appendChild(item);
+    });
+  }
+
+  input.addEventListener("input", function () {
+    var query = input.value.trim().toLowerCase();
+    results.hidden = query === "";
+    tree.hidden = query !== "";
+    if (query !== "") {
+      render(search(query));
+    }
+  });
+})();
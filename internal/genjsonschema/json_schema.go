@@ -0,0 +1,79 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package genjsonschema
+
+import (
+	"fmt"
+
+	pgs "github.com/lyft/protoc-gen-star"
+	"htdvisser.dev/protoc-gen-collection/internal/gendatafiles"
+)
+
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+type JSONSchemaModule struct {
+	*pgs.ModuleBase
+	encoder gendatafiles.Encoder
+}
+
+func JSONSchema() *JSONSchemaModule {
+	return &JSONSchemaModule{
+		ModuleBase: &pgs.ModuleBase{},
+		encoder:    gendatafiles.JSONEncoder{},
+	}
+}
+
+func (m *JSONSchemaModule) Name() string { return "json_schema" }
+
+func (m *JSONSchemaModule) Execute(targets map[string]pgs.File, packages map[string]pgs.Package) []pgs.Artifact {
+	for _, pkg := range packages {
+		m.generatePackage(pkg)
+	}
+	return m.Artifacts()
+}
+
+// SchemaFileName returns the name of the file that contains the schema of
+// the given message or enum.
+func SchemaFileName(entity pgs.Entity) string {
+	return fmt.Sprintf("%s.schema.json", gendatafiles.EntityName(entity))
+}
+
+// FileRef returns a $ref to the schema file of entity, relative to the
+// directory of the schema files of pkg.
+func FileRef(pkg pgs.Package, entity pgs.Entity) string {
+	if entity.Package().ProtoName() == pkg.ProtoName() {
+		return SchemaFileName(entity)
+	}
+	return fmt.Sprintf("../%s/%s", entity.Package().ProtoName(), SchemaFileName(entity))
+}
+
+func (m *JSONSchemaModule) generatePackage(pkg pgs.Package) {
+	basePath := []string{"jsonschema", pkg.ProtoName().String()}
+	builder := Builder{
+		Ref: func(entity pgs.Entity) string { return FileRef(pkg, entity) },
+	}
+	for _, file := range pkg.Files() {
+		if !file.BuildTarget() {
+			continue
+		}
+		for _, enum := range file.AllEnums() {
+			m.writeSchema(append(basePath, SchemaFileName(enum)), builder.EnumSchema(enum))
+		}
+		for _, message := range file.AllMessages() {
+			if message.IsMapEntry() {
+				continue
+			}
+			m.writeSchema(append(basePath, SchemaFileName(message)), builder.MessageSchema(message))
+		}
+	}
+}
+
+func (m *JSONSchemaModule) writeSchema(path []string, schema gendatafiles.MapSlice) {
+	schema = append(gendatafiles.MapSlice{{Key: "$schema", Value: draft}}, schema...)
+	if content, err := m.encoder.EncodeData(schema); err != nil {
+		m.AddError(err.Error())
+	} else {
+		m.OverwriteCustomFile(m.JoinPath(path...), content, 0644)
+	}
+}
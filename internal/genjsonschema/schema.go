@@ -0,0 +1,526 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package genjsonschema
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+
+	pgs "github.com/lyft/protoc-gen-star"
+	"htdvisser.dev/protoc-gen-collection/internal/gendatafiles"
+)
+
+// Builder builds JSON Schemas that follow the proto3 JSON mapping.
+type Builder struct {
+	// Ref returns the $ref to the schema of a message or enum.
+	Ref func(pgs.Entity) string
+}
+
+func describe(schema gendatafiles.MapSlice, entity gendatafiles.Entity) gendatafiles.MapSlice {
+	if entity.Comment != "" {
+		schema = schema.Set("description", entity.Comment)
+	}
+	if entity.Deprecated {
+		schema = schema.Set("deprecated", true)
+	}
+	return schema
+}
+
+func ruleFloat(v interface{}) float64 {
+	rv := reflect.ValueOf(gendatafiles.RuleValue(v))
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	default:
+		return 0
+	}
+}
+
+func (b Builder) EnumSchema(src pgs.Enum) gendatafiles.MapSlice {
+	enum := gendatafiles.BuildEnum(src)
+	schema := gendatafiles.MapSlice{{Key: "title", Value: gendatafiles.EntityName(src).String()}}
+	schema = describe(schema, enum.Entity)
+	var names, numbers []interface{}
+	for _, value := range enum.Values {
+		names = append(names, value.Name)
+		for _, alias := range value.Aliases {
+			names = append(names, alias.Name)
+		}
+		numbers = append(numbers, value.Value)
+	}
+	schema = schema.Set("type", []string{"string", "integer"})
+	return schema.Set("enum", append(names, numbers...))
+}
+
+func oneOfSchema(names []string, required bool) gendatafiles.MapSlice {
+	options := make([]interface{}, 0, len(names)+1)
+	for _, name := range names {
+		options = append(options, gendatafiles.MapSlice{{Key: "required", Value: []string{name}}})
+	}
+	if !required {
+		none := gendatafiles.MapSlice{{Key: "anyOf", Value: append([]interface{}(nil), options...)}}
+		options = append(options, gendatafiles.MapSlice{{Key: "not", Value: none}})
+	}
+	return gendatafiles.MapSlice{{Key: "oneOf", Value: options}}
+}
+
+func (b Builder) MessageSchema(src pgs.Message) gendatafiles.MapSlice {
+	message := gendatafiles.BuildMessage(src)
+	rules := message.Rules == nil || !message.Rules.Disabled && !message.Rules.Ignored
+	schema := gendatafiles.MapSlice{{Key: "title", Value: gendatafiles.EntityName(src).String()}}
+	schema = describe(schema, message.Entity)
+	schema = schema.Set("type", "object")
+	var (
+		properties = gendatafiles.MapSlice{}
+		required   []string
+		jsonNames  = make(map[string]string)
+	)
+	for i, field := range message.Fields {
+		jsonNames[field.Name.String()] = field.JSONName
+		properties = append(properties, gendatafiles.MapItem{
+			Key:   field.JSONName,
+			Value: b.FieldSchema(field, src.Fields()[i].Type(), rules),
+		})
+		if (rules && field.Rules.Required) || hasBehavior(field, "REQUIRED") {
+			required = append(required, field.JSONName)
+		}
+	}
+	schema = schema.Set("properties", properties)
+	if len(required) > 0 {
+		schema = schema.Set("required", required)
+	}
+	var oneOfs []interface{}
+	for _, oneof := range message.OneOfs {
+		var names []string
+		for _, name := range oneof.FieldNames {
+			names = append(names, jsonNames[name.String()])
+		}
+		if len(names) > 1 || oneof.Required {
+			oneOfs = append(oneOfs, oneOfSchema(names, oneof.Required))
+		}
+	}
+	if rules && message.Rules != nil {
+		for _, rule := range message.Rules.Oneofs {
+			var names []string
+			for _, name := range rule.Fields {
+				names = append(names, jsonNames[name])
+			}
+			if len(names) > 1 || rule.Required {
+				oneOfs = append(oneOfs, oneOfSchema(names, rule.Required))
+			}
+		}
+	}
+	switch len(oneOfs) {
+	case 0:
+	case 1:
+		schema = append(schema, oneOfs[0].(gendatafiles.MapSlice)...)
+	default:
+		schema = schema.Set("allOf", oneOfs)
+	}
+	return schema
+}
+
+func hasBehavior(field gendatafiles.Field, behavior string) bool {
+	for _, b := range field.Behavior {
+		if b == behavior {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldSchema returns the schema of a field. If rules is false, the
+// validation rules of the field are not added to the schema.
+func (b Builder) FieldSchema(field gendatafiles.Field, t pgs.FieldType, rules bool) gendatafiles.MapSlice {
+	var schema gendatafiles.MapSlice
+	switch {
+	case t.IsRepeated():
+		schema = gendatafiles.MapSlice{
+			{Key: "type", Value: "array"},
+			{Key: "items", Value: b.ElemSchema(t.Element(), field.Repeated.Rules, rules)},
+		}
+		if rules && !field.Rules.Skip {
+			if field.Rules.MinItems != 0 {
+				schema = schema.Set("minItems", field.Rules.MinItems)
+			}
+			if field.Rules.MaxItems != 0 {
+				schema = schema.Set("maxItems", field.Rules.MaxItems)
+			}
+			if field.Rules.Unique {
+				schema = schema.Set("uniqueItems", true)
+			}
+		}
+	case t.IsMap():
+		schema = gendatafiles.MapSlice{
+			{Key: "type", Value: "object"},
+			{Key: "propertyNames", Value: keySchema(t.Key(), field.MapKey.Rules, rules)},
+			{Key: "additionalProperties", Value: b.ElemSchema(t.Element(), field.MapValue.Rules, rules)},
+		}
+		if rules && !field.Rules.Skip {
+			if field.Rules.MinPairs != 0 {
+				schema = schema.Set("minProperties", field.Rules.MinPairs)
+			}
+			if field.Rules.MaxPairs != 0 {
+				schema = schema.Set("maxProperties", field.Rules.MaxPairs)
+			}
+		}
+	default:
+		schema = b.ElemSchema(t, field.Rules, rules)
+	}
+	schema = describe(schema, field.Entity)
+	if hasBehavior(field, "OUTPUT_ONLY") {
+		schema = schema.Set("readOnly", true)
+	}
+	if hasBehavior(field, "INPUT_ONLY") {
+		schema = schema.Set("writeOnly", true)
+	}
+	return schema
+}
+
+// ElemSchema returns the schema of a single (not repeated or map) value.
+func (b Builder) ElemSchema(t gendatafiles.PGSFieldType, fieldRules gendatafiles.FieldRules, rules bool) gendatafiles.MapSlice {
+	rules = rules && !fieldRules.Skip
+	var schema gendatafiles.MapSlice
+	switch {
+	case t.IsEnum() && t.Enum().FullyQualifiedName() == ".google.protobuf.NullValue":
+		return gendatafiles.MapSlice{{Key: "type", Value: "null"}}
+	case t.IsEnum():
+		schema = gendatafiles.MapSlice{{Key: "$ref", Value: b.Ref(t.Enum())}}
+		if rules {
+			schema = enumRules(schema, t.Enum(), fieldRules)
+		}
+	case t.IsEmbed():
+		if schema, ok := wellKnownSchema(t.Embed(), fieldRules, rules); ok {
+			return schema
+		}
+		return gendatafiles.MapSlice{{Key: "$ref", Value: b.Ref(t.Embed())}}
+	default:
+		schema = scalarSchema(t.ProtoType())
+		if rules {
+			schema = scalarRules(schema, t.ProtoType(), fieldRules)
+		}
+	}
+	if rules && fieldRules.IgnoreEmpty && !t.IsEnum() {
+		zero := gendatafiles.MapSlice{{Key: "const", Value: gendatafiles.ProtoTypeDefault(t.ProtoType())}}
+		schema = gendatafiles.MapSlice{{Key: "anyOf", Value: []interface{}{zero, schema}}}
+	}
+	return schema
+}
+
+func scalarSchema(t pgs.ProtoType) gendatafiles.MapSlice {
+	switch t {
+	case pgs.DoubleT, pgs.FloatT:
+		return gendatafiles.MapSlice{
+			{Key: "type", Value: []string{"number", "string"}},
+			{Key: "pattern", Value: "^(NaN|-?Infinity)$"},
+		}
+	case pgs.Int64T, pgs.SInt64, pgs.SFixed64:
+		return gendatafiles.MapSlice{
+			{Key: "type", Value: []string{"integer", "string"}},
+			{Key: "pattern", Value: "^-?[0-9]+$"},
+		}
+	case pgs.UInt64T, pgs.Fixed64T:
+		return gendatafiles.MapSlice{
+			{Key: "type", Value: []string{"integer", "string"}},
+			{Key: "pattern", Value: "^[0-9]+$"},
+			{Key: "minimum", Value: 0},
+		}
+	case pgs.Int32T, pgs.SInt32, pgs.SFixed32:
+		return gendatafiles.MapSlice{
+			{Key: "type", Value: "integer"},
+			{Key: "minimum", Value: math.MinInt32},
+			{Key: "maximum", Value: math.MaxInt32},
+		}
+	case pgs.UInt32T, pgs.Fixed32T:
+		return gendatafiles.MapSlice{
+			{Key: "type", Value: "integer"},
+			{Key: "minimum", Value: 0},
+			{Key: "maximum", Value: int64(math.MaxUint32)},
+		}
+	case pgs.BoolT:
+		return gendatafiles.MapSlice{{Key: "type", Value: "boolean"}}
+	case pgs.BytesT:
+		return gendatafiles.MapSlice{
+			{Key: "type", Value: "string"},
+			{Key: "contentEncoding", Value: "base64"},
+		}
+	default:
+		return gendatafiles.MapSlice{{Key: "type", Value: "string"}}
+	}
+}
+
+func keySchema(t gendatafiles.PGSFieldType, fieldRules gendatafiles.FieldRules, rules bool) gendatafiles.MapSlice {
+	switch t.ProtoType() {
+	case pgs.StringT:
+		schema := scalarSchema(pgs.StringT)
+		if rules && !fieldRules.Skip {
+			schema = scalarRules(schema, pgs.StringT, fieldRules)
+		}
+		return schema
+	case pgs.BoolT:
+		return gendatafiles.MapSlice{
+			{Key: "type", Value: "string"},
+			{Key: "enum", Value: []string{"true", "false"}},
+		}
+	default:
+		return gendatafiles.MapSlice{
+			{Key: "type", Value: "string"},
+			{Key: "pattern", Value: "^-?[0-9]+$"},
+		}
+	}
+}
+
+func wellKnownSchema(src pgs.Message, fieldRules gendatafiles.FieldRules, rules bool) (gendatafiles.MapSlice, bool) {
+	if src.FullyQualifiedName() == ".google.protobuf.FieldMask" {
+		return gendatafiles.MapSlice{{Key: "type", Value: "string"}}, true
+	}
+	if !src.IsWellKnown() {
+		return nil, false
+	}
+	switch src.WellKnownType() {
+	case pgs.AnyWKT:
+		typeURL := gendatafiles.MapSlice{{Key: "type", Value: "string"}}
+		if rules && fieldRules.In != nil {
+			typeURL = typeURL.Set("enum", fieldRules.In)
+		}
+		if rules && fieldRules.NotIn != nil {
+			typeURL = typeURL.Set("not", gendatafiles.MapSlice{{Key: "enum", Value: fieldRules.NotIn}})
+		}
+		return gendatafiles.MapSlice{
+			{Key: "type", Value: "object"},
+			{Key: "properties", Value: gendatafiles.MapSlice{{Key: "@type", Value: typeURL}}},
+			{Key: "required", Value: []string{"@type"}},
+		}, true
+	case pgs.DurationWKT:
+		return gendatafiles.MapSlice{
+			{Key: "type", Value: "string"},
+			{Key: "pattern", Value: `^-?[0-9]+(\.[0-9]{1,9})?s$`},
+		}, true
+	case pgs.TimestampWKT:
+		return gendatafiles.MapSlice{
+			{Key: "type", Value: "string"},
+			{Key: "format", Value: "date-time"},
+		}, true
+	case pgs.EmptyWKT:
+		return gendatafiles.MapSlice{
+			{Key: "type", Value: "object"},
+			{Key: "maxProperties", Value: 0},
+		}, true
+	case pgs.StructWKT:
+		return gendatafiles.MapSlice{{Key: "type", Value: "object"}}, true
+	case pgs.ValueWKT:
+		return gendatafiles.MapSlice{}, true
+	case pgs.ListValueWKT:
+		return gendatafiles.MapSlice{{Key: "type", Value: "array"}}, true
+	default: // Wrappers
+		t := src.Fields()[0].Type().ProtoType()
+		schema := scalarSchema(t)
+		if rules && !fieldRules.Skip {
+			schema = scalarRules(schema, t, fieldRules)
+		}
+		return gendatafiles.MapSlice{{Key: "anyOf", Value: []interface{}{
+			schema,
+			gendatafiles.MapSlice{{Key: "type", Value: "null"}},
+		}}}, true
+	}
+}
+
+func enumRules(schema gendatafiles.MapSlice, src pgs.Enum, fieldRules gendatafiles.FieldRules) gendatafiles.MapSlice {
+	// In the JSON mapping, enum values can be given by name or by number.
+	values := func(v interface{}) []interface{} {
+		var values []interface{}
+		rv := reflect.ValueOf(gendatafiles.RuleValue(v))
+		if rv.Kind() != reflect.Slice {
+			rv = reflect.ValueOf([]interface{}{gendatafiles.RuleValue(v)})
+		}
+		for i := 0; i < rv.Len(); i++ {
+			number := rv.Index(i).Interface()
+			for _, value := range src.Values() {
+				if value.Value() == number {
+					values = append(values, value.Name().String())
+				}
+			}
+			values = append(values, number)
+		}
+		return values
+	}
+	if fieldRules.Const != nil {
+		schema = schema.Set("enum", values(fieldRules.Const))
+	} else if fieldRules.In != nil {
+		schema = schema.Set("enum", values(fieldRules.In))
+	}
+	if fieldRules.NotIn != nil {
+		schema = schema.Set("not", gendatafiles.MapSlice{{Key: "enum", Value: values(fieldRules.NotIn)}})
+	}
+	return schema
+}
+
+func scalarRules(schema gendatafiles.MapSlice, t pgs.ProtoType, fieldRules gendatafiles.FieldRules) gendatafiles.MapSlice {
+	if is64Bit(t) {
+		// 64-bit integers can be numbers or strings, so values are allowed in
+		// both forms.
+		if fieldRules.Const != nil {
+			schema = schema.Set("enum", bothForms(fieldRules.Const))
+		}
+		if fieldRules.In != nil {
+			schema = schema.Set("enum", bothForms(fieldRules.In))
+		}
+		if fieldRules.NotIn != nil {
+			schema = schema.Set("not", gendatafiles.MapSlice{{Key: "enum", Value: bothForms(fieldRules.NotIn)}})
+		}
+	} else {
+		if fieldRules.Const != nil {
+			schema = schema.Set("const", gendatafiles.RuleValue(fieldRules.Const))
+		}
+		if fieldRules.In != nil {
+			schema = schema.Set("enum", fieldRules.In)
+		}
+		if fieldRules.NotIn != nil {
+			schema = schema.Set("not", gendatafiles.MapSlice{{Key: "enum", Value: fieldRules.NotIn}})
+		}
+	}
+	switch t {
+	case pgs.StringT:
+		return stringRules(schema, fieldRules)
+	case pgs.BoolT, pgs.BytesT:
+		return schema
+	default:
+		return numberRules(schema, t, fieldRules)
+	}
+}
+
+func is64Bit(t pgs.ProtoType) bool {
+	switch t {
+	case pgs.Int64T, pgs.SInt64, pgs.SFixed64, pgs.UInt64T, pgs.Fixed64T:
+		return true
+	}
+	return false
+}
+
+// bothForms returns the rule value (or values) as numbers and as strings.
+func bothForms(v interface{}) []interface{} {
+	v = gendatafiles.RuleValue(v)
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return []interface{}{v, fmt.Sprint(v)}
+	}
+	values := make([]interface{}, 0, 2*rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		values = append(values, bothForms(rv.Index(i).Interface())...)
+	}
+	return values
+}
+
+func numberRules(schema gendatafiles.MapSlice, t pgs.ProtoType, fieldRules gendatafiles.FieldRules) gendatafiles.MapSlice {
+	if fieldRules.Finite && (t == pgs.DoubleT || t == pgs.FloatT) {
+		schema = schema.Unset("pattern").Set("type", "number")
+	}
+	lowerKey, lower := "minimum", gendatafiles.RuleValue(fieldRules.Gte)
+	if fieldRules.Gt != nil {
+		lowerKey, lower = "exclusiveMinimum", gendatafiles.RuleValue(fieldRules.Gt)
+	}
+	upperKey, upper := "maximum", gendatafiles.RuleValue(fieldRules.Lte)
+	if fieldRules.Lt != nil {
+		upperKey, upper = "exclusiveMaximum", gendatafiles.RuleValue(fieldRules.Lt)
+	}
+	if is64Bit(t) && (lower != nil || upper != nil) {
+		// Bounds do not apply to strings.
+		schema = schema.Unset("pattern").Set("type", "integer")
+	}
+	if lower != nil && upper != nil && ruleFloat(lower) > ruleFloat(upper) {
+		// The value must lie outside of the range.
+		return schema.Set("anyOf", []interface{}{
+			gendatafiles.MapSlice{{Key: lowerKey, Value: lower}},
+			gendatafiles.MapSlice{{Key: upperKey, Value: upper}},
+		})
+	}
+	if lower != nil {
+		schema = schema.Unset("minimum").Unset("exclusiveMinimum").Set(lowerKey, lower)
+	}
+	if upper != nil {
+		schema = schema.Unset("maximum").Unset("exclusiveMaximum").Set(upperKey, upper)
+	}
+	return schema
+}
+
+func stringRules(schema gendatafiles.MapSlice, fieldRules gendatafiles.FieldRules) gendatafiles.MapSlice {
+	if fieldRules.MinLen != 0 {
+		schema = schema.Set("minLength", fieldRules.MinLen)
+	}
+	if fieldRules.MaxLen != 0 {
+		schema = schema.Set("maxLength", fieldRules.MaxLen)
+	}
+	if fieldRules.Len != 0 {
+		schema = schema.Set("minLength", fieldRules.Len)
+		schema = schema.Set("maxLength", fieldRules.Len)
+	}
+	var patterns []string
+	if fieldRules.Pattern != "" {
+		patterns = append(patterns, fieldRules.Pattern)
+	}
+	if prefix := gendatafiles.RuleValue(fieldRules.Prefix); prefix != nil {
+		patterns = append(patterns, "^"+regexp.QuoteMeta(fmt.Sprint(prefix)))
+	}
+	if suffix := gendatafiles.RuleValue(fieldRules.Suffix); suffix != nil {
+		patterns = append(patterns, regexp.QuoteMeta(fmt.Sprint(suffix))+"$")
+	}
+	if contains := gendatafiles.RuleValue(fieldRules.Contains); contains != nil {
+		patterns = append(patterns, regexp.QuoteMeta(fmt.Sprint(contains)))
+	}
+	switch {
+	case fieldRules.TUUID:
+		patterns = append(patterns, "^[0-9a-fA-F]{32}$")
+	case fieldRules.ULID:
+		patterns = append(patterns, "^[0-7][0-9A-HJKMNP-TV-Za-hjkmnp-tv-z]{25}$")
+	}
+	var allOf []interface{}
+	for i, pattern := range patterns {
+		if i == 0 {
+			schema = schema.Set("pattern", pattern)
+		} else {
+			allOf = append(allOf, gendatafiles.MapSlice{{Key: "pattern", Value: pattern}})
+		}
+	}
+	if notContains := gendatafiles.RuleValue(fieldRules.NotContains); notContains != nil {
+		allOf = append(allOf, gendatafiles.MapSlice{{Key: "not", Value: gendatafiles.MapSlice{
+			{Key: "pattern", Value: regexp.QuoteMeta(fmt.Sprint(notContains))},
+		}}})
+	}
+	if len(allOf) > 0 {
+		schema = schema.Set("allOf", allOf)
+	}
+	format := func(format string) gendatafiles.MapSlice {
+		return gendatafiles.MapSlice{{Key: "format", Value: format}}
+	}
+	switch {
+	case fieldRules.Email:
+		schema = schema.Set("format", "email")
+	case fieldRules.Hostname:
+		schema = schema.Set("format", "hostname")
+	case fieldRules.URI:
+		schema = schema.Set("format", "uri")
+	case fieldRules.URIRef:
+		schema = schema.Set("format", "uri-reference")
+	case fieldRules.UUID:
+		schema = schema.Set("format", "uuid")
+	case fieldRules.IPv4:
+		schema = schema.Set("format", "ipv4")
+	case fieldRules.IPv6:
+		schema = schema.Set("format", "ipv6")
+	case fieldRules.IP:
+		schema = schema.Set("anyOf", []interface{}{format("ipv4"), format("ipv6")})
+	case fieldRules.Address:
+		schema = schema.Set("anyOf", []interface{}{format("hostname"), format("ipv4"), format("ipv6")})
+	}
+	// JSON Schema has no formats for host_and_port, IP prefixes, IP addresses
+	// with prefix lengths, protobuf names and well-known regexes, and patterns
+	// for them would be too involved, so those rules are left out.
+	return schema
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package genjsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	pgs "github.com/lyft/protoc-gen-star"
+	"google.golang.org/protobuf/proto"
+	"htdvisser.dev/protoc-gen-collection/internal/gendatafiles"
+)
+
+func TestScalarRules(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		t     pgs.ProtoType
+		rules gendatafiles.FieldRules
+		want  string
+	}{
+		{
+			name:  "int32 range",
+			t:     pgs.Int32T,
+			rules: gendatafiles.FieldRules{Gt: proto.Int32(5), Lte: proto.Int32(10)},
+			want:  `{"type":"integer","exclusiveMinimum":5,"maximum":10}`,
+		},
+		{
+			name:  "int32 exclusive range",
+			t:     pgs.Int32T,
+			rules: gendatafiles.FieldRules{Gt: proto.Int32(10), Lt: proto.Int32(5)},
+			want:  `{"type":"integer","minimum":-2147483648,"maximum":2147483647,"anyOf":[{"exclusiveMinimum":10},{"exclusiveMaximum":5}]}`,
+		},
+		{
+			name:  "int32 const",
+			t:     pgs.Int32T,
+			rules: gendatafiles.FieldRules{Const: proto.Int32(5)},
+			want:  `{"type":"integer","minimum":-2147483648,"maximum":2147483647,"const":5}`,
+		},
+		{
+			name:  "double finite",
+			t:     pgs.DoubleT,
+			rules: gendatafiles.FieldRules{Finite: true, In: []float64{1.5, 2.5}},
+			want:  `{"type":"number","enum":[1.5,2.5]}`,
+		},
+		{
+			name:  "int64 const",
+			t:     pgs.Int64T,
+			rules: gendatafiles.FieldRules{Const: proto.Int64(5)},
+			want:  `{"type":["integer","string"],"pattern":"^-?[0-9]+$","enum":[5,"5"]}`,
+		},
+		{
+			name:  "int64 in and not_in",
+			t:     pgs.SInt64,
+			rules: gendatafiles.FieldRules{In: []int64{1, 2}, NotIn: []int64{3}},
+			want:  `{"type":["integer","string"],"pattern":"^-?[0-9]+$","enum":[1,"1",2,"2"],"not":{"enum":[3,"3"]}}`,
+		},
+		{
+			name:  "uint64 gte",
+			t:     pgs.UInt64T,
+			rules: gendatafiles.FieldRules{Gte: proto.Uint64(10)},
+			want:  `{"type":"integer","minimum":10}`,
+		},
+		{
+			name:  "fixed64 exclusive range",
+			t:     pgs.Fixed64T,
+			rules: gendatafiles.FieldRules{Gt: proto.Uint64(10), Lt: proto.Uint64(5)},
+			want:  `{"type":"integer","minimum":0,"anyOf":[{"exclusiveMinimum":10},{"exclusiveMaximum":5}]}`,
+		},
+		{
+			name:  "uuid",
+			t:     pgs.StringT,
+			rules: gendatafiles.FieldRules{UUID: true},
+			want:  `{"type":"string","format":"uuid"}`,
+		},
+		{
+			name:  "tuuid",
+			t:     pgs.StringT,
+			rules: gendatafiles.FieldRules{TUUID: true},
+			want:  `{"type":"string","pattern":"^[0-9a-fA-F]{32}$"}`,
+		},
+		{
+			name:  "ulid with prefix",
+			t:     pgs.StringT,
+			rules: gendatafiles.FieldRules{ULID: true, Prefix: proto.String("0")},
+			want:  `{"type":"string","pattern":"^0","allOf":[{"pattern":"^[0-7][0-9A-HJKMNP-TV-Za-hjkmnp-tv-z]{25}$"}]}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(scalarRules(scalarSchema(tt.t), tt.t, tt.rules))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package genmarkdown
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	pgs "github.com/lyft/protoc-gen-star"
+	"htdvisser.dev/protoc-gen-collection/internal/gendatafiles"
+)
+
+type MarkdownModule struct {
+	*pgs.ModuleBase
+}
+
+func Markdown() *MarkdownModule {
+	return &MarkdownModule{
+		ModuleBase: &pgs.ModuleBase{},
+	}
+}
+
+func (m *MarkdownModule) Name() string { return "markdown" }
+
+func (m *MarkdownModule) Execute(targets map[string]pgs.File, packages map[string]pgs.Package) []pgs.Artifact {
+	for _, pkg := range packages {
+		var page Page
+		if page.WritePackage(pkg) {
+			filename := fmt.Sprintf("%s.md", pkg.ProtoName())
+			m.OverwriteCustomFile(m.JoinPath("markdown", filename), page.String(), 0644)
+		}
+	}
+	return m.Artifacts()
+}
+
+// Anchor returns the anchor of a heading, in the way GitHub generates them.
+func Anchor(heading string) string {
+	var anchor strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			anchor.WriteRune(r)
+		case r == ' ':
+			anchor.WriteRune('-')
+		}
+	}
+	return anchor.String()
+}
+
+// Link returns a relative link from the page of pkg to the documentation of
+// the referenced message or enum. Types of other packages link to the page of
+// that package. Well-known types are not linked.
+func Link(pkg pgs.Name, ref gendatafiles.Ref) string {
+	switch refPackage := ref.PackageName(); refPackage {
+	case pkg:
+		return fmt.Sprintf("[%s](#%s)", ref.Name, Anchor(ref.Name.String()))
+	case "google.protobuf":
+		return fmt.Sprintf("`%s.%s`", refPackage, ref.Name)
+	default:
+		return fmt.Sprintf("[%s.%s](%s.md#%s)", refPackage, ref.Name, refPackage, Anchor(ref.Name.String()))
+	}
+}
+
+func escapeCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(strings.TrimSpace(s), "\n", "<br>")
+}
+
+func codeCell(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + strings.ReplaceAll(s, "|", `\|`) + "`"
+}
+
+func elemType(pkg pgs.Name, elem gendatafiles.FieldTypeElem) string {
+	switch {
+	case elem.Enum.Name != "":
+		return Link(pkg, elem.Enum)
+	case elem.Message.Name != "":
+		return Link(pkg, elem.Message)
+	default:
+		return "`" + elem.Type + "`"
+	}
+}
+
+// FieldType returns the type of a field, with links from the page of pkg to
+// messages and enums.
+func FieldType(pkg pgs.Name, fieldType gendatafiles.FieldType) string {
+	return fieldType.Format(func(elem gendatafiles.FieldTypeElem) string {
+		return elemType(pkg, elem)
+	}, html.EscapeString)
+}
+
+// Page is a Markdown page that documents a package.
+type Page struct {
+	strings.Builder
+	pkg pgs.Name
+}
+
+func (p *Page) heading(level int, heading string) {
+	if p.Len() > 0 && !strings.HasSuffix(p.String(), "\n\n") {
+		p.WriteString("\n")
+	}
+	fmt.Fprintf(p, "%s %s\n\n", strings.Repeat("#", level), heading)
+}
+
+func (p *Page) paragraph(text string) {
+	if text != "" {
+		fmt.Fprintf(p, "%s\n\n", text)
+	}
+}
+
+// WritePackage writes the documentation of the build targets in pkg to the
+// page. It returns false if pkg has no build targets.
+func (p *Page) WritePackage(pkg pgs.Package) bool {
+	data := gendatafiles.BuildPackage(pkg)
+	if data.IsEmpty() {
+		return false
+	}
+	services, messages, enums := data.Services, data.Messages, data.Enums
+
+	p.pkg = pkg.ProtoName()
+	p.heading(1, pkg.ProtoName().String())
+	for _, section := range []struct {
+		title string
+		names []pgs.Name
+	}{
+		{"Services", serviceNames(services)},
+		{"Messages", messageNames(messages)},
+		{"Enums", enumNames(enums)},
+	} {
+		if len(section.names) == 0 {
+			continue
+		}
+		fmt.Fprintf(p, "- [%s](#%s)\n", section.title, Anchor(section.title))
+		for _, name := range section.names {
+			fmt.Fprintf(p, "  - [%s](#%s)\n", name, Anchor(name.String()))
+		}
+	}
+
+	if len(services) > 0 {
+		p.heading(2, "Services")
+		for _, service := range services {
+			p.writeService(service)
+		}
+	}
+	if len(messages) > 0 {
+		p.heading(2, "Messages")
+		for _, message := range messages {
+			p.writeMessage(message)
+		}
+	}
+	if len(enums) > 0 {
+		p.heading(2, "Enums")
+		for _, enum := range enums {
+			p.writeEnum(enum)
+		}
+	}
+	return true
+}
+
+func serviceNames(services []gendatafiles.Service) []pgs.Name {
+	names := make([]pgs.Name, len(services))
+	for i, service := range services {
+		names[i] = service.Name
+	}
+	return names
+}
+
+func messageNames(messages []gendatafiles.Message) []pgs.Name {
+	names := make([]pgs.Name, len(messages))
+	for i, message := range messages {
+		names[i] = message.Name
+	}
+	return names
+}
+
+func enumNames(enums []gendatafiles.Enum) []pgs.Name {
+	names := make([]pgs.Name, len(enums))
+	for i, enum := range enums {
+		names[i] = enum.Name
+	}
+	return names
+}
+
+func streamType(pkg pgs.Name, stream gendatafiles.Stream) string {
+	if stream.Stream {
+		return "stream " + Link(pkg, stream.Ref)
+	}
+	return Link(pkg, stream.Ref)
+}
+
+func (p *Page) writeService(service gendatafiles.Service) {
+	p.heading(3, service.Name.String())
+	p.paragraph(service.Description())
+	if service.DefaultHost != "" {
+		p.paragraph(fmt.Sprintf("Default host: `%s`", service.DefaultHost))
+	}
+	for _, item := range service.Methods {
+		method := item.Value.(gendatafiles.Method)
+		p.heading(4, fmt.Sprintf("%s.%s", service.Name, method.Name))
+		p.paragraph(fmt.Sprintf("`%s`(%s) returns (%s)", method.Name, streamType(p.pkg, method.Input), streamType(p.pkg, method.Output)))
+		p.paragraph(method.Description())
+		if len(method.HTTP) == 0 {
+			continue
+		}
+		p.WriteString("| Method | Path | Body | Response body |\n")
+		p.WriteString("| ------ | ---- | ---- | ------------- |\n")
+		for _, rule := range method.HTTP {
+			body := rule.Input
+			if rule.BodyMode == "message" {
+				body = "*"
+			}
+			fmt.Fprintf(p, "| %s | %s | %s | %s |\n", rule.Method, codeCell(rule.Path), codeCell(body), codeCell(rule.Output))
+		}
+	}
+}
+
+func (p *Page) writeMessage(message gendatafiles.Message) {
+	p.heading(3, message.Name.String())
+	p.paragraph(message.Description())
+	if len(message.Fields) > 0 {
+		p.WriteString("| Field | Type | Default | Rules | Description |\n")
+		p.WriteString("| ----- | ---- | ------- | ----- | ----------- |\n")
+		for _, field := range message.Fields {
+			rules := field.FormatRules()
+			for i, rule := range rules {
+				rules[i] = codeCell(rule)
+			}
+			fmt.Fprintf(p, "| `%s` | %s | %s | %s | %s |\n",
+				field.Name,
+				FieldType(p.pkg, field.FieldType),
+				codeCell(field.FormatDefault()),
+				strings.Join(rules, "<br>"),
+				escapeCell(field.Description()),
+			)
+		}
+		p.WriteString("\n")
+	}
+	for _, oneof := range message.OneOfs {
+		fields := make([]string, len(oneof.FieldNames))
+		for i, name := range oneof.FieldNames {
+			fields[i] = codeCell(name.String())
+		}
+		required := ""
+		if oneof.Required {
+			required = " (required)"
+		}
+		p.paragraph(fmt.Sprintf("Oneof `%s`%s: %s", oneof.Name, required, strings.Join(fields, ", ")))
+	}
+	if message.Rules != nil {
+		for _, cel := range message.Rules.CEL {
+			p.paragraph(fmt.Sprintf("Rule %s: `%s`", codeCell(cel.ID), cel.Expression))
+		}
+	}
+}
+
+func (p *Page) writeEnum(enum gendatafiles.Enum) {
+	p.heading(3, enum.Name.String())
+	p.paragraph(enum.Description())
+	p.WriteString("| Name | Number | Description |\n")
+	p.WriteString("| ---- | ------ | ----------- |\n")
+	for _, value := range enum.Values {
+		fmt.Fprintf(p, "| `%s` | %d | %s |\n", value.Name, value.Value, escapeCell(value.Description()))
+		for _, alias := range value.Aliases {
+			fmt.Fprintf(p, "| `%s` | %d | %s |\n", alias.Name, alias.Value, escapeCell(alias.Description()))
+		}
+	}
+}
@@ -0,0 +1,447 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package genopenapi
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	pgs "github.com/lyft/protoc-gen-star"
+	"htdvisser.dev/protoc-gen-collection/internal/gendatafiles"
+	"htdvisser.dev/protoc-gen-collection/internal/genjsonschema"
+)
+
+const statusSchema = "google.rpc.Status"
+
+var operationMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true, "trace": true,
+}
+
+var versionPattern = regexp.MustCompile(`^v[0-9]+`)
+
+type OpenAPIModule struct {
+	*pgs.ModuleBase
+}
+
+func OpenAPI() *OpenAPIModule {
+	return &OpenAPIModule{
+		ModuleBase: &pgs.ModuleBase{},
+	}
+}
+
+func (m *OpenAPIModule) Name() string { return "openapi" }
+
+func (m *OpenAPIModule) Execute(targets map[string]pgs.File, packages map[string]pgs.Package) []pgs.Artifact {
+	var encoder gendatafiles.Encoder = gendatafiles.JSONEncoder{}
+	switch format := m.Parameters().Str("format"); format {
+	case "", "json":
+	case "yaml":
+		encoder = gendatafiles.YAMLEncoder{}
+	default:
+		m.Failf("unknown format %q", format)
+	}
+	for _, pkg := range packages {
+		if !hasBuildTargets(pkg) {
+			continue
+		}
+		document := m.buildDocument(pkg)
+		if content, err := encoder.EncodeData(document); err != nil {
+			m.AddError(err.Error())
+		} else {
+			filename := fmt.Sprintf("%s.%s", pkg.ProtoName(), encoder.FileExtension())
+			m.OverwriteCustomFile(m.JoinPath("openapi", filename), content, 0644)
+		}
+	}
+	return m.Artifacts()
+}
+
+func hasBuildTargets(pkg pgs.Package) bool {
+	for _, file := range pkg.Files() {
+		if file.BuildTarget() {
+			return true
+		}
+	}
+	return false
+}
+
+func componentName(entity pgs.Entity) string {
+	return strings.TrimPrefix(entity.FullyQualifiedName(), ".")
+}
+
+// parameter builds a parameter with the given schema, moving the description
+// of the field from the schema to the parameter.
+func parameter(name, in string, required bool, schema gendatafiles.MapSlice) gendatafiles.MapSlice {
+	param := gendatafiles.MapSlice{
+		{Key: "name", Value: name},
+		{Key: "in", Value: in},
+	}
+	if description, ok := schema.Get("description"); ok {
+		param = param.Set("description", description)
+		schema = schema.Unset("description")
+	}
+	if required {
+		param = param.Set("required", true)
+	}
+	if deprecated, ok := schema.Get("deprecated"); ok {
+		param = param.Set("deprecated", deprecated)
+		schema = schema.Unset("deprecated")
+	}
+	return param.Set("schema", schema)
+}
+
+// components collects the schemas of the messages and enums that are
+// referenced from the document.
+type components struct {
+	builder genjsonschema.Builder
+	pending map[string]pgs.Entity
+	schemas map[string]gendatafiles.MapSlice
+}
+
+func newComponents() *components {
+	c := &components{
+		pending: make(map[string]pgs.Entity),
+		schemas: make(map[string]gendatafiles.MapSlice),
+	}
+	c.builder = genjsonschema.Builder{Ref: c.ref}
+	return c
+}
+
+func (c *components) ref(entity pgs.Entity) string {
+	name := componentName(entity)
+	if _, ok := c.schemas[name]; !ok {
+		c.pending[name] = entity
+	}
+	return "#/components/schemas/" + name
+}
+
+func (c *components) build() gendatafiles.MapSlice {
+	for len(c.pending) > 0 {
+		for name, entity := range c.pending {
+			delete(c.pending, name)
+			c.schemas[name] = nil // Mark as seen before building recursive schemas.
+			switch entity := entity.(type) {
+			case pgs.Message:
+				c.schemas[name] = c.builder.MessageSchema(entity)
+			case pgs.Enum:
+				c.schemas[name] = c.builder.EnumSchema(entity)
+			}
+		}
+	}
+	if _, ok := c.schemas[statusSchema]; !ok {
+		c.schemas[statusSchema] = gendatafiles.MapSlice{
+			{Key: "type", Value: "object"},
+			{Key: "properties", Value: gendatafiles.MapSlice{
+				{Key: "code", Value: gendatafiles.MapSlice{{Key: "type", Value: "integer"}}},
+				{Key: "message", Value: gendatafiles.MapSlice{{Key: "type", Value: "string"}}},
+				{Key: "details", Value: gendatafiles.MapSlice{
+					{Key: "type", Value: "array"},
+					{Key: "items", Value: gendatafiles.MapSlice{{Key: "type", Value: "object"}}},
+				}},
+			}},
+		}
+	}
+	schemas := make(gendatafiles.MapSlice, 0, len(c.schemas))
+	for name, schema := range c.schemas {
+		schemas = append(schemas, gendatafiles.MapItem{Key: name, Value: schema})
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Key < schemas[j].Key })
+	return schemas
+}
+
+func (m *OpenAPIModule) buildDocument(pkg pgs.Package) gendatafiles.MapSlice {
+	components := newComponents()
+	version := m.Parameters().Str("version")
+	if version == "" {
+		version = "0.0.0"
+		parts := strings.Split(pkg.ProtoName().String(), ".")
+		if last := parts[len(parts)-1]; versionPattern.MatchString(last) {
+			version = last
+		}
+	}
+	var (
+		servers []interface{}
+		hosts   = make(map[string]bool)
+		tags    []interface{}
+		paths   = gendatafiles.MapSlice{}
+	)
+	for _, file := range pkg.Files() {
+		if !file.BuildTarget() {
+			continue
+		}
+		for _, enum := range file.AllEnums() {
+			components.ref(enum)
+		}
+		for _, message := range file.AllMessages() {
+			if !message.IsMapEntry() {
+				components.ref(message)
+			}
+		}
+		for _, src := range file.Services() {
+			service := gendatafiles.BuildService(src)
+			if service.DefaultHost != "" && !hosts[service.DefaultHost] {
+				hosts[service.DefaultHost] = true
+				servers = append(servers, gendatafiles.MapSlice{{Key: "url", Value: "https://" + service.DefaultHost}})
+			}
+			tag := gendatafiles.MapSlice{{Key: "name", Value: src.Name().String()}}
+			if service.Comment != "" {
+				tag = tag.Set("description", service.Comment)
+			}
+			tags = append(tags, tag)
+			for _, methodSrc := range src.Methods() {
+				paths = m.addMethod(paths, components, methodSrc)
+			}
+		}
+	}
+	document := gendatafiles.MapSlice{
+		{Key: "openapi", Value: "3.1.0"},
+		{Key: "info", Value: gendatafiles.MapSlice{
+			{Key: "title", Value: pkg.ProtoName().String()},
+			{Key: "version", Value: version},
+		}},
+	}
+	if len(servers) > 0 {
+		document = document.Set("servers", servers)
+	}
+	if len(tags) > 0 {
+		document = document.Set("tags", tags)
+	}
+	document = document.Set("paths", paths)
+	return document.Set("components", gendatafiles.MapSlice{{Key: "schemas", Value: components.build()}})
+}
+
+// pathParameter is a path parameter of an OpenAPI path. It is either a whole
+// path variable, or a wildcard of a variable whose pattern has literals.
+type pathParameter struct {
+	name     string
+	variable gendatafiles.PathVariable
+	wildcard string
+}
+
+// openAPIPath converts a path template to an OpenAPI path. The literals of
+// variable patterns are written into the path, so that the paths of resources
+// such as "shelves/*" and "shelves/*/books/*" do not collide.
+func openAPIPath(template *gendatafiles.PathTemplate) (string, []pathParameter) {
+	var (
+		path       strings.Builder
+		parameters []pathParameter
+		names      = make(map[string]bool)
+		variables  = template.Variables
+	)
+	for _, segment := range template.Segments {
+		if segment.Variable != "" {
+			names[segment.Variable] = true
+		}
+	}
+	for _, segment := range template.Segments {
+		path.WriteByte('/')
+		switch {
+		case segment.Variable != "":
+			variable := variables[0]
+			variables = variables[1:]
+			if !hasLiterals(variable) {
+				path.WriteString("{" + variable.FieldPath + "}")
+				parameters = append(parameters, pathParameter{name: variable.FieldPath, variable: variable})
+				continue
+			}
+			var wildcards int
+			for i, segment := range variable.Segments {
+				if i > 0 {
+					path.WriteByte('/')
+				}
+				if segment.Wildcard == "" {
+					path.WriteString(segment.Literal)
+					continue
+				}
+				wildcards++
+				name := fmt.Sprintf("%s_%d", variable.FieldPath, wildcards)
+				if i > 0 && !names[variable.Segments[i-1].Literal] {
+					name = variable.Segments[i-1].Literal
+				}
+				names[name] = true
+				path.WriteString("{" + name + "}")
+				parameters = append(parameters, pathParameter{name: name, variable: variable, wildcard: segment.Wildcard})
+			}
+		case segment.Wildcard != "":
+			path.WriteString(segment.Wildcard)
+		default:
+			path.WriteString(segment.Literal)
+		}
+	}
+	if template.Verb != "" {
+		path.WriteString(":" + template.Verb)
+	}
+	return path.String(), parameters
+}
+
+func hasLiterals(variable gendatafiles.PathVariable) bool {
+	for _, segment := range variable.Segments {
+		if segment.Literal != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// variablePattern returns a regular expression for the values of a path
+// variable that matches more than a single path segment.
+func variablePattern(variable gendatafiles.PathVariable) string {
+	if variable.Pattern == "" || variable.Pattern == "*" {
+		return ""
+	}
+	parts := make([]string, len(variable.Segments))
+	for i, segment := range variable.Segments {
+		parts[i] = segmentPattern(segment)
+	}
+	return "^" + strings.Join(parts, "/") + "$"
+}
+
+func segmentPattern(segment gendatafiles.PathSegment) string {
+	switch segment.Wildcard {
+	case "*":
+		return "[^/]+"
+	case "**":
+		return ".+"
+	default:
+		return regexp.QuoteMeta(segment.Literal)
+	}
+}
+
+func (m *OpenAPIModule) fieldSchema(components *components, src pgs.Message, path string) (gendatafiles.MapSlice, bool) {
+	field, err := gendatafiles.LookupField(src, path)
+	if err != nil {
+		return nil, false // Reported by the errors of the method.
+	}
+	return components.builder.FieldSchema(gendatafiles.BuildField(field), field.Type(), true), true
+}
+
+func (m *OpenAPIModule) addMethod(paths gendatafiles.MapSlice, components *components, src pgs.Method) gendatafiles.MapSlice {
+	method := gendatafiles.BuildMethod(src)
+	name := fmt.Sprintf("%s.%s", src.Service().Name(), src.Name())
+	for _, err := range method.Errors() {
+		m.AddError(err.Error())
+	}
+	if len(method.HTTP) == 0 {
+		return paths
+	}
+	var streaming string
+	switch {
+	case src.ClientStreaming():
+		m.Logf("warning: skipping client streaming method %s", name)
+		return paths
+	case src.ServerStreaming():
+		m.Logf("warning: method %s is server streaming", name)
+		streaming = "server"
+	}
+	for i, rule := range method.HTTP {
+		httpMethod := strings.ToLower(rule.Method)
+		if !operationMethods[httpMethod] {
+			m.Logf("warning: skipping binding %s %s of method %s", rule.Method, rule.Path, name)
+			continue
+		}
+		if rule.Template == nil {
+			m.Logf("warning: skipping binding %s %s of method %s: invalid path template", rule.Method, rule.Path, name)
+			continue
+		}
+		path, pathParameters := openAPIPath(rule.Template)
+		item, _ := paths.Get(path)
+		pathItem, _ := item.(gendatafiles.MapSlice)
+		if _, ok := pathItem.Get(httpMethod); ok {
+			m.Logf("warning: skipping duplicate binding %s %s of method %s", rule.Method, rule.Path, name)
+			continue
+		}
+
+		operationID := strings.ReplaceAll(name, ".", "_")
+		if i > 0 {
+			operationID = fmt.Sprintf("%s_%d", operationID, i+1)
+		}
+		operation := gendatafiles.MapSlice{
+			{Key: "operationId", Value: operationID},
+			{Key: "tags", Value: []string{src.Service().Name().String()}},
+		}
+		if method.Comment != "" {
+			operation = operation.Set("description", method.Comment)
+		}
+		if method.Deprecated {
+			operation = operation.Set("deprecated", true)
+		}
+
+		var parameters []interface{}
+		for _, param := range pathParameters {
+			if param.wildcard != "" {
+				parameters = append(parameters, parameter(param.name, "path", true, gendatafiles.MapSlice{
+					{Key: "description", Value: fmt.Sprintf("Part of `%s`.", param.variable.FieldPath)},
+					{Key: "type", Value: "string"},
+					{Key: "pattern", Value: "^" + segmentPattern(gendatafiles.PathSegment{Wildcard: param.wildcard}) + "$"},
+				}))
+				continue
+			}
+			schema, ok := m.fieldSchema(components, src.Input(), param.variable.FieldPath)
+			if !ok {
+				continue
+			}
+			if pattern := variablePattern(param.variable); pattern != "" {
+				schema = schema.Set("pattern", pattern)
+			}
+			parameters = append(parameters, parameter(param.name, "path", true, schema))
+		}
+		for _, param := range rule.QueryParameters {
+			schema, ok := m.fieldSchema(components, src.Input(), param.Path)
+			if !ok {
+				continue
+			}
+			parameters = append(parameters, parameter(param.Path, "query", false, schema))
+		}
+		if len(parameters) > 0 {
+			operation = operation.Set("parameters", parameters)
+		}
+
+		var requestSchema gendatafiles.MapSlice
+		switch rule.BodyMode {
+		case "message":
+			requestSchema = gendatafiles.MapSlice{{Key: "$ref", Value: components.ref(src.Input())}}
+		case "field":
+			requestSchema, _ = m.fieldSchema(components, src.Input(), rule.Input)
+		}
+		if requestSchema != nil {
+			operation = operation.Set("requestBody", gendatafiles.MapSlice{
+				{Key: "required", Value: true},
+				{Key: "content", Value: gendatafiles.MapSlice{
+					{Key: "application/json", Value: gendatafiles.MapSlice{{Key: "schema", Value: requestSchema}}},
+				}},
+			})
+
+		}
+
+		responseSchema := gendatafiles.MapSlice{{Key: "$ref", Value: components.ref(src.Output())}}
+		if rule.Output != "" {
+			responseSchema, _ = m.fieldSchema(components, src.Output(), rule.Output)
+		}
+		operation = operation.Set("responses", gendatafiles.MapSlice{
+			{Key: "200", Value: gendatafiles.MapSlice{
+				{Key: "description", Value: "A successful response."},
+				{Key: "content", Value: gendatafiles.MapSlice{
+					{Key: "application/json", Value: gendatafiles.MapSlice{{Key: "schema", Value: responseSchema}}},
+				}},
+			}},
+			{Key: "default", Value: gendatafiles.MapSlice{
+				{Key: "description", Value: "An unexpected error response."},
+				{Key: "content", Value: gendatafiles.MapSlice{
+					{Key: "application/json", Value: gendatafiles.MapSlice{{Key: "schema", Value: gendatafiles.MapSlice{
+						{Key: "$ref", Value: "#/components/schemas/" + statusSchema},
+					}}}},
+				}},
+			}},
+		})
+
+		if streaming != "" {
+			operation = operation.Set("x-streaming", streaming)
+		}
+
+		paths = paths.Set(path, pathItem.Set(httpMethod, operation))
+	}
+	return paths
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 Hylke Visser
+// SPDX-License-Identifier: Apache-2.0
+
+package genopenapi
+
+import (
+	"reflect"
+	"testing"
+
+	"htdvisser.dev/protoc-gen-collection/internal/gendatafiles"
+)
+
+func TestOpenAPIPath(t *testing.T) {
+	for _, tt := range []struct {
+		template       string
+		wantPath       string
+		wantParameters []string
+		wantPatterns   []string
+	}{
+		{
+			template:       "/v1/shelves/{shelf}",
+			wantPath:       "/v1/shelves/{shelf}",
+			wantParameters: []string{"shelf"},
+			wantPatterns:   []string{""},
+		},
+		{
+			template:       "/v1/{name=shelves/*}",
+			wantPath:       "/v1/shelves/{shelves}",
+			wantParameters: []string{"shelves"},
+			wantPatterns:   []string{"^shelves/[^/]+$"},
+		},
+		{
+			template:       "/v1/{name=shelves/*/books/*}",
+			wantPath:       "/v1/shelves/{shelves}/books/{books}",
+			wantParameters: []string{"shelves", "books"},
+			wantPatterns:   []string{"^shelves/[^/]+/books/[^/]+$", "^shelves/[^/]+/books/[^/]+$"},
+		},
+		{
+			template:       "/v1/{parent=shelves/*}/books/{book.name=books/*/pages/**}",
+			wantPath:       "/v1/shelves/{shelves}/books/books/{books}/pages/{pages}",
+			wantParameters: []string{"shelves", "books", "pages"},
+			wantPatterns:   []string{"^shelves/[^/]+$", "^books/[^/]+/pages/.+$", "^books/[^/]+/pages/.+$"},
+		},
+		{
+			template:       "/v1/{shelves=shelves/*}",
+			wantPath:       "/v1/shelves/{shelves_1}",
+			wantParameters: []string{"shelves_1"},
+			wantPatterns:   []string{"^shelves/[^/]+$"},
+		},
+		{
+			template:       "/v1/{name=*/*}:get",
+			wantPath:       "/v1/{name}:get",
+			wantParameters: []string{"name"},
+			wantPatterns:   []string{"^[^/]+/[^/]+$"},
+		},
+		{
+			template:       "/v1/{name=**}",
+			wantPath:       "/v1/{name}",
+			wantParameters: []string{"name"},
+			wantPatterns:   []string{"^.+$"},
+		},
+	} {
+		t.Run(tt.template, func(t *testing.T) {
+			template, err := gendatafiles.ParsePathTemplate(tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path, parameters := openAPIPath(template)
+			if path != tt.wantPath {
+				t.Errorf("path is %q, want %q", path, tt.wantPath)
+			}
+			var names, patterns []string
+			for _, param := range parameters {
+				names = append(names, param.name)
+				patterns = append(patterns, variablePattern(param.variable))
+			}
+			if !reflect.DeepEqual(names, tt.wantParameters) {
+				t.Errorf("parameters are %q, want %q", names, tt.wantParameters)
+			}
+			if !reflect.DeepEqual(patterns, tt.wantPatterns) {
+				t.Errorf("patterns are %q, want %q", patterns, tt.wantPatterns)
+			}
+		})
+	}
+}